@@ -1,37 +1,135 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/odysseymorphey/quotes-service/internal/config"
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
 	"github.com/odysseymorphey/quotes-service/internal/server"
+	"github.com/odysseymorphey/quotes-service/pkg/storage/memory"
 	"github.com/odysseymorphey/quotes-service/pkg/storage/postgres"
+	"github.com/odysseymorphey/quotes-service/pkg/storage/sqlite"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	// The runtime image has no zoneinfo for the tz parameter of GET /quotes/daily.
+	_ "time/tzdata"
 )
 
-type Mock struct{}
-
 func main() {
-	db, err := postgres.New(fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("POSTGRES_HOST"),
-		os.Getenv("POSTGRES_PORT"),
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_DB"),
-	),
-	)
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("Can't open database: %v", err)
+		log.Fatalf("Can't load config: %v", err)
 	}
-	s := server.New(db)
 
-	go s.Run()
+	if cfg.PrintConfig {
+		if err := cfg.Write(os.Stdout); err != nil {
+			log.Fatalf("Can't print config: %v", err)
+		}
+	}
 
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
 
-	<-sig
-	s.Stop()
-	os.Exit(1)
+	if cfg.PrintConfig {
+		return
+	}
+
+	logger := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	slog.SetDefault(logger)
+
+	if err := run(cfg, logger); err != nil {
+		logger.Error("Server stopped", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// run serves until SIGINT or SIGTERM and then shuts down. It returns nil if
+// every in-flight request finished in time and the storage closed cleanly.
+func run(cfg *config.Config, logger *slog.Logger) error {
+	db, err := newRepository(cfg.Storage, logger)
+	if err != nil {
+		return fmt.Errorf("can't open database: %w", err)
+	}
+	s := server.New(cfg.Server, db, newRotations(db), logger)
+
+	logger.Info("Serving", slog.String("addr", cfg.Server.Addr), slog.String("storage", cfg.Storage.Driver))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Run()
+	}()
+
+	select {
+	case err := <-errc:
+		// The server stopped by itself, there is nothing to drain.
+		return errors.Join(fmt.Errorf("server failed: %w", err), db.Close())
+	case <-ctx.Done():
+	}
+
+	// A second signal kills the process right away.
+	cancel()
+	logger.Info("Shutting down")
+
+	shutdown := context.Background()
+	if cfg.Server.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdown, cancel = context.WithTimeout(shutdown, cfg.Server.ShutdownTimeout)
+		defer cancel()
+	}
+
+	if err := s.Stop(shutdown); err != nil {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+
+	return <-errc
+}
+
+// maxRotations caps how many client rotations are kept in memory.
+const maxRotations = 10_000
+
+// newRotations keeps client rotations in the database if it can, and in
+// memory otherwise.
+func newRotations(db repository.Repository) repository.RotationStore {
+	if rs, ok := db.(repository.RotationStore); ok {
+		return rs
+	}
+
+	return memory.NewRotations(maxRotations)
+}
+
+func newRepository(cfg config.Storage, logger *slog.Logger) (repository.Repository, error) {
+	switch cfg.Driver {
+	case "postgres":
+		db, err := postgres.New(cfg.Postgres.DSN())
+		if err != nil {
+			return nil, err
+		}
+
+		db.Log = logger
+		db.Db.SetMaxOpenConns(cfg.Postgres.MaxOpenConns)
+		db.Db.SetMaxIdleConns(cfg.Postgres.MaxIdleConns)
+		db.Db.SetConnMaxLifetime(cfg.Postgres.ConnMaxLifetime)
+		db.RotationTTL = cfg.Postgres.RotationTTL
+
+		return db, nil
+	case "sqlite":
+		return sqlite.New(cfg.SQLite.Path)
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Driver)
+	}
 }
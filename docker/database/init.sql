@@ -1,5 +1,4 @@
-CREATE TABLE IF NOT EXISTS quotes (
-    id SERIAL PRIMARY KEY,
-    author VARCHAR(30) NOT NULL,
-    quote TEXT NOT NULL
-);
\ No newline at end of file
+-- The service creates its tables and migrates them on start, see
+-- pkg/storage/postgres. The extension is created here as well, since it
+-- needs a superuser, which the service may not connect as.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
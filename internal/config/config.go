@@ -0,0 +1,324 @@
+// Package config loads the service configuration from, in increasing order
+// of precedence, defaults, a YAML file, the environment and command line
+// flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server  Server  `yaml:"server"`
+	Storage Storage `yaml:"storage"`
+	Log     Log     `yaml:"log"`
+	// PrintConfig asks to print the configuration instead of serving. It
+	// only comes from the -print-config flag.
+	PrintConfig bool `yaml:"-"`
+}
+
+// Server configures the HTTP server. Zero timeouts and limits mean no
+// timeout and no limit.
+type Server struct {
+	Addr         string        `yaml:"addr"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// on shutdown before their connections are closed.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// RequestTimeout is the deadline of a handler and of the queries it
+	// runs. BulkRequestTimeout replaces it for imports and exports.
+	RequestTimeout     time.Duration `yaml:"request_timeout"`
+	BulkRequestTimeout time.Duration `yaml:"bulk_request_timeout"`
+	// MaxHeaderBytes falls back to the 1 MB net/http default when zero.
+	MaxHeaderBytes int `yaml:"max_header_bytes"`
+	// MaxBodyBytes caps request bodies. MaxBulkBodyBytes replaces it for
+	// imports.
+	MaxBodyBytes     int64 `yaml:"max_body_bytes"`
+	MaxBulkBodyBytes int64 `yaml:"max_bulk_body_bytes"`
+	// CORSOrigins are the origins browser scripts may call the API from,
+	// "*" for any. CORS is off when empty.
+	CORSOrigins []string `yaml:"cors_origins,omitempty"`
+	TLS         TLS      `yaml:"tls"`
+}
+
+// TLS is enabled when both files are set.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+type Storage struct {
+	// Driver is postgres, sqlite or memory.
+	Driver   string   `yaml:"driver"`
+	Postgres Postgres `yaml:"postgres"`
+	SQLite   SQLite   `yaml:"sqlite"`
+}
+
+type Postgres struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Database string `yaml:"database"`
+	SSLMode  string `yaml:"sslmode"`
+	// MaxOpenConns is unlimited when zero.
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// RotationTTL is how long the rotation of a client through the random
+	// quotes is kept after its last use. Zero keeps rotations forever.
+	RotationTTL time.Duration `yaml:"rotation_ttl"`
+}
+
+// DSN returns the connection URL, with every part escaped.
+func (p Postgres) DSN() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(p.User, p.Password),
+		Host:     net.JoinHostPort(p.Host, strconv.Itoa(p.Port)),
+		Path:     "/" + p.Database,
+		RawQuery: url.Values{"sslmode": {p.SSLMode}}.Encode(),
+	}
+
+	return u.String()
+}
+
+type SQLite struct {
+	Path string `yaml:"path"`
+}
+
+// Log configures the logger. Format is logging.FormatJSON or
+// logging.FormatText.
+type Log struct {
+	Level  slog.Level `yaml:"level"`
+	Format string     `yaml:"format"`
+}
+
+// Default returns the configuration used for everything that isn't set.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:         ":8080",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: time.Minute,
+			IdleTimeout:  2 * time.Minute,
+			// Below the 30s that Docker and Kubernetes wait before SIGKILL.
+			ShutdownTimeout: 20 * time.Second,
+			// Shorter than WriteTimeout, so that there is time left to
+			// report the timeout.
+			RequestTimeout:     5 * time.Second,
+			BulkRequestTimeout: 50 * time.Second,
+			MaxHeaderBytes:     64 << 10,
+			MaxBodyBytes:       1 << 20,
+			MaxBulkBodyBytes:   32 << 20,
+		},
+		Storage: Storage{
+			Driver: "postgres",
+			Postgres: Postgres{
+				Host:            "localhost",
+				Port:            5432,
+				User:            "postgres",
+				Database:        "postgres",
+				SSLMode:         "disable",
+				MaxOpenConns:    10,
+				MaxIdleConns:    5,
+				ConnMaxLifetime: 30 * time.Minute,
+				RotationTTL:     30 * 24 * time.Hour,
+			},
+			SQLite: SQLite{Path: "quotes.db"},
+		},
+		Log: Log{
+			Level:  slog.LevelInfo,
+			Format: logging.FormatJSON,
+		},
+	}
+}
+
+// envs maps flags to the environment variables that set the same field.
+var envs = []struct{ flag, env string }{
+	{"addr", "HTTP_ADDR"},
+	{"read-timeout", "HTTP_READ_TIMEOUT"},
+	{"write-timeout", "HTTP_WRITE_TIMEOUT"},
+	{"idle-timeout", "HTTP_IDLE_TIMEOUT"},
+	{"shutdown-timeout", "HTTP_SHUTDOWN_TIMEOUT"},
+	{"request-timeout", "HTTP_REQUEST_TIMEOUT"},
+	{"bulk-request-timeout", "HTTP_BULK_REQUEST_TIMEOUT"},
+	{"max-header-bytes", "HTTP_MAX_HEADER_BYTES"},
+	{"max-body-bytes", "HTTP_MAX_BODY_BYTES"},
+	{"max-bulk-body-bytes", "HTTP_MAX_BULK_BODY_BYTES"},
+	{"cors-origins", "HTTP_CORS_ORIGINS"},
+	{"tls-cert-file", "TLS_CERT_FILE"},
+	{"tls-key-file", "TLS_KEY_FILE"},
+	{"storage", "STORAGE"},
+	{"sqlite-path", "SQLITE_PATH"},
+	{"postgres-host", "POSTGRES_HOST"},
+	{"postgres-port", "POSTGRES_PORT"},
+	{"postgres-user", "POSTGRES_USER"},
+	{"postgres-password", "POSTGRES_PASSWORD"},
+	{"postgres-db", "POSTGRES_DB"},
+	{"postgres-sslmode", "POSTGRES_SSLMODE"},
+	{"postgres-max-open-conns", "POSTGRES_MAX_OPEN_CONNS"},
+	{"postgres-max-idle-conns", "POSTGRES_MAX_IDLE_CONNS"},
+	{"postgres-conn-max-lifetime", "POSTGRES_CONN_MAX_LIFETIME"},
+	{"postgres-rotation-ttl", "POSTGRES_ROTATION_TTL"},
+	{"log-level", "LOG_LEVEL"},
+	{"log-format", "LOG_FORMAT"},
+}
+
+// Load builds the configuration from the defaults, the YAML file named by the
+// -config flag or CONFIG_FILE, the environment read with getenv and the flags
+// in args, each overriding the ones before. It doesn't validate the result.
+// It returns flag.ErrHelp if args ask for help.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	c := Default()
+
+	// The flags are bound to the fields of c, so parsing them first only
+	// tells which ones are set; they are set again once the file and the
+	// environment have been read.
+	var file string
+	fs := flagSet(&c, &file, getenv("CONFIG_FILE"))
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	if file != "" {
+		if err := c.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range envs {
+		if v := getenv(e.env); v != "" {
+			if err := fs.Set(e.flag, v); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", e.env, err)
+			}
+		}
+	}
+
+	for name, v := range set {
+		if err := fs.Set(name, v); err != nil {
+			return nil, fmt.Errorf("invalid -%s: %w", name, err)
+		}
+	}
+
+	return &c, nil
+}
+
+func flagSet(c *Config, file *string, defaultFile string) *flag.FlagSet {
+	fs := flag.NewFlagSet("quotes-service", flag.ContinueOnError)
+
+	fs.StringVar(file, "config", defaultFile, "YAML configuration `file`")
+	fs.BoolVar(&c.PrintConfig, "print-config", false, "print the configuration and exit")
+
+	s := &c.Server
+	fs.StringVar(&s.Addr, "addr", s.Addr, "listen address")
+	fs.DurationVar(&s.ReadTimeout, "read-timeout", s.ReadTimeout, "timeout for reading a request")
+	fs.DurationVar(&s.WriteTimeout, "write-timeout", s.WriteTimeout, "timeout for writing a response")
+	fs.DurationVar(&s.IdleTimeout, "idle-timeout", s.IdleTimeout, "timeout for idle keep-alive connections")
+	fs.DurationVar(&s.ShutdownTimeout, "shutdown-timeout", s.ShutdownTimeout, "time to let in-flight requests finish on shutdown")
+	fs.DurationVar(&s.RequestTimeout, "request-timeout", s.RequestTimeout, "deadline of a request")
+	fs.DurationVar(&s.BulkRequestTimeout, "bulk-request-timeout", s.BulkRequestTimeout, "deadline of an import or an export")
+	fs.IntVar(&s.MaxHeaderBytes, "max-header-bytes", s.MaxHeaderBytes, "maximum size of request headers")
+	fs.Int64Var(&s.MaxBodyBytes, "max-body-bytes", s.MaxBodyBytes, "maximum size of a request body")
+	fs.Int64Var(&s.MaxBulkBodyBytes, "max-bulk-body-bytes", s.MaxBulkBodyBytes, "maximum size of an import body")
+	fs.Var((*listValue)(&s.CORSOrigins), "cors-origins", "comma-separated origins allowed by CORS, * for any")
+	fs.StringVar(&s.TLS.CertFile, "tls-cert-file", s.TLS.CertFile, "TLS certificate `file`")
+	fs.StringVar(&s.TLS.KeyFile, "tls-key-file", s.TLS.KeyFile, "TLS key `file`")
+
+	st := &c.Storage
+	fs.StringVar(&st.Driver, "storage", st.Driver, "storage: postgres, sqlite or memory")
+	fs.StringVar(&st.SQLite.Path, "sqlite-path", st.SQLite.Path, "SQLite database `file`")
+
+	p := &st.Postgres
+	fs.StringVar(&p.Host, "postgres-host", p.Host, "Postgres host")
+	fs.IntVar(&p.Port, "postgres-port", p.Port, "Postgres port")
+	fs.StringVar(&p.User, "postgres-user", p.User, "Postgres user")
+	fs.StringVar(&p.Password, "postgres-password", p.Password, "Postgres password")
+	fs.StringVar(&p.Database, "postgres-db", p.Database, "Postgres database")
+	fs.StringVar(&p.SSLMode, "postgres-sslmode", p.SSLMode, "Postgres sslmode")
+	fs.IntVar(&p.MaxOpenConns, "postgres-max-open-conns", p.MaxOpenConns, "maximum open Postgres connections, 0 for no limit")
+	fs.IntVar(&p.MaxIdleConns, "postgres-max-idle-conns", p.MaxIdleConns, "maximum idle Postgres connections")
+	fs.DurationVar(&p.ConnMaxLifetime, "postgres-conn-max-lifetime", p.ConnMaxLifetime, "maximum Postgres connection lifetime, 0 for no limit")
+	fs.DurationVar(&p.RotationTTL, "postgres-rotation-ttl", p.RotationTTL, "how long unused client rotations are kept, 0 for ever")
+
+	l := &c.Log
+	fs.TextVar(&l.Level, "log-level", l.Level, "minimum log level: debug, info, warn or error")
+	fs.StringVar(&l.Format, "log-format", l.Format, "log format: json or text")
+
+	return fs
+}
+
+// listValue is a flag.Value for comma-separated lists. Setting it replaces
+// the list, so that flags override the file instead of adding to it.
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
+}
+
+// readFile decodes the YAML file at path over c. Unknown keys are errors, so
+// that typos don't go unnoticed.
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("can't open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("can't read config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Write writes c as YAML in the file format, with the password masked.
+func (c Config) Write(w io.Writer) error {
+	if c.Storage.Postgres.Password != "" {
+		c.Storage.Postgres.Password = "********"
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
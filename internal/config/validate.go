@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Validate reports every problem with c at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	s := c.Server
+	_, _, err := net.SplitHostPort(s.Addr)
+	check(err == nil, "server.addr: %q is not a host:port address", s.Addr)
+	check(s.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(s.WriteTimeout >= 0, "server.write_timeout: must not be negative")
+	check(s.IdleTimeout >= 0, "server.idle_timeout: must not be negative")
+	check(s.ShutdownTimeout >= 0, "server.shutdown_timeout: must not be negative")
+	check(s.RequestTimeout >= 0, "server.request_timeout: must not be negative")
+	check(s.BulkRequestTimeout >= 0, "server.bulk_request_timeout: must not be negative")
+	// A handler that outlives the write timeout can't report its timeout.
+	if s.WriteTimeout > 0 {
+		check(s.RequestTimeout > 0 && s.RequestTimeout < s.WriteTimeout, "server.request_timeout: must be shorter than write_timeout")
+		check(s.BulkRequestTimeout > 0 && s.BulkRequestTimeout < s.WriteTimeout, "server.bulk_request_timeout: must be shorter than write_timeout")
+	}
+	check(s.MaxHeaderBytes >= 0, "server.max_header_bytes: must not be negative")
+	check(s.MaxBodyBytes >= 0, "server.max_body_bytes: must not be negative")
+	check(s.MaxBulkBodyBytes >= 0, "server.max_bulk_body_bytes: must not be negative")
+
+	check((s.TLS.CertFile == "") == (s.TLS.KeyFile == ""), "server.tls: cert_file and key_file must be set together")
+	for _, path := range []string{s.TLS.CertFile, s.TLS.KeyFile} {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, "server.tls: %v", err)
+		}
+	}
+
+	switch c.Storage.Driver {
+	case "postgres":
+		p := c.Storage.Postgres
+		check(p.Host != "", "storage.postgres.host: is required")
+		check(p.Port > 0 && p.Port < 65536, "storage.postgres.port: %d is not a port", p.Port)
+		check(p.User != "", "storage.postgres.user: is required")
+		check(p.Database != "", "storage.postgres.database: is required")
+		check(slices.Contains(sslModes, p.SSLMode), "storage.postgres.sslmode: must be one of %v", sslModes)
+		check(p.MaxOpenConns >= 0, "storage.postgres.max_open_conns: must not be negative")
+		check(p.MaxIdleConns >= 0, "storage.postgres.max_idle_conns: must not be negative")
+		check(p.MaxOpenConns == 0 || p.MaxIdleConns <= p.MaxOpenConns, "storage.postgres.max_idle_conns: must not exceed max_open_conns")
+		check(p.ConnMaxLifetime >= 0, "storage.postgres.conn_max_lifetime: must not be negative")
+		check(p.RotationTTL >= 0, "storage.postgres.rotation_ttl: must not be negative")
+	case "sqlite":
+		check(c.Storage.SQLite.Path != "", "storage.sqlite.path: is required")
+	case "memory":
+	default:
+		check(false, "storage.driver: unknown storage %q", c.Storage.Driver)
+	}
+
+	check(c.Log.Format == logging.FormatJSON || c.Log.Format == logging.FormatText,
+		"log.format: must be %s or %s", logging.FormatJSON, logging.FormatText)
+
+	return errors.Join(errs...)
+}
@@ -1,25 +1,22 @@
 package handlers
 
 import (
-	"encoding/json"
 	"github.com/odysseymorphey/quotes-service/internal/models"
-	"log"
 	"net/http"
 )
 
 func (h *BaseHandler) AddQuote(w http.ResponseWriter, r *http.Request) {
 	var quote models.Quote
-	if err := json.NewDecoder(r.Body).Decode(&quote); err != nil {
-		log.Printf("Failed request body decoding: %v", err)
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if !h.decodeValid(w, r, &quote) {
 		return
 	}
 
-	if err := h.Repo.AddQuote(r.Context(), quote); err != nil {
-		log.Printf("Failed to add quote: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	created, err := h.Repo.AddQuote(r.Context(), quote)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to add quote")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Location", "/quotes/"+created.Id)
+	h.writeJSON(w, r, http.StatusCreated, created)
 }
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+)
+
+var errTooManyQuotes = fmt.Errorf("at most %d quotes per import", models.MaxBulkQuotes)
+
+// AddQuotesBulk imports a JSON array or an NDJSON stream of quotes. By default
+// the import is atomic: either every quote is stored or none is. With
+// mode=partial valid quotes are stored one by one and the response reports the
+// outcome of every item.
+func (h *BaseHandler) AddQuotesBulk(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "atomic" && mode != "partial" {
+		http.Error(w, "Invalid mode", http.StatusBadRequest)
+		return
+	}
+
+	items, err := readBulkItems(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, errTooManyQuotes):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case errors.As(err, &maxBytesErr):
+			h.writeError(w, r, err, "")
+			return
+		}
+
+		h.log().InfoContext(r.Context(), "Failed request body decoding", slog.Any("error", err))
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(items) == 0 {
+		h.writeError(w, r, &models.ValidationError{Errors: []models.FieldError{
+			{Message: "at least one quote is required"},
+		}}, "")
+		return
+	}
+
+	quotes := make([]models.Quote, len(items))
+	var invalid []models.BulkItemResult
+
+	for i, raw := range items {
+		if errs := decodeBulkItem(raw, &quotes[i]); errs != nil {
+			invalid = append(invalid, models.BulkItemResult{Index: i, Status: models.BulkStatusInvalid, Errors: errs})
+		}
+	}
+
+	if mode == "partial" {
+		h.addQuotesPartial(w, r, quotes, invalid)
+		return
+	}
+
+	if len(invalid) > 0 {
+		h.writeJSON(w, r, http.StatusUnprocessableEntity, models.BulkResult{Results: invalid})
+		return
+	}
+
+	created, err := h.Repo.AddQuotes(r.Context(), quotes)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to import quotes")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusCreated, models.BulkResult{Created: created})
+}
+
+// addQuotesPartial stores the valid quotes one by one. Once the request is
+// canceled or runs past its deadline it stops and fails the whole request,
+// rather than reporting every remaining quote as failed.
+func (h *BaseHandler) addQuotesPartial(w http.ResponseWriter, r *http.Request, quotes []models.Quote, invalid []models.BulkItemResult) {
+	ctx := r.Context()
+	result := models.BulkResult{Results: make([]models.BulkItemResult, 0, len(quotes))}
+
+	for i, q := range quotes {
+		if len(invalid) > 0 && invalid[0].Index == i {
+			result.Results = append(result.Results, invalid[0])
+			invalid = invalid[1:]
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			h.writeError(w, r, err, "Failed to import quotes")
+			return
+		}
+
+		created, err := h.Repo.AddQuote(ctx, q)
+		if err != nil {
+			item := models.BulkItemResult{Index: i, Status: models.BulkStatusFailed}
+
+			switch {
+			case errors.Is(err, repository.ErrCanceled), ctx.Err() != nil:
+				h.writeError(w, r, errors.Join(err, ctx.Err()), "Failed to import quotes")
+				return
+			case errors.Is(err, repository.ErrConflict):
+				h.log().InfoContext(ctx, "Failed to import quote", slog.Int("index", i), slog.Any("error", err))
+				item.Errors = []models.FieldError{{Message: "conflicts with a stored quote"}}
+			case errors.Is(err, repository.ErrInvalid):
+				h.log().InfoContext(ctx, "Failed to import quote", slog.Int("index", i), slog.Any("error", err))
+				item.Status = models.BulkStatusInvalid
+				item.Errors = []models.FieldError{{Message: "rejected by the storage"}}
+			default:
+				h.log().ErrorContext(ctx, "Failed to import quote", slog.Int("index", i), slog.Any("error", err))
+				item.Errors = []models.FieldError{{Message: "can't store quote"}}
+			}
+
+			result.Results = append(result.Results, item)
+			continue
+		}
+
+		result.Created++
+		result.Results = append(result.Results, models.BulkItemResult{Index: i, Status: models.BulkStatusCreated, Quote: created})
+	}
+
+	h.writeJSON(w, r, http.StatusOK, result)
+}
+
+// readBulkItems splits the body into raw items without decoding them, so that
+// a malformed quote is reported per item instead of failing the whole body.
+func readBulkItems(r *http.Request) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(r.Body)
+
+	var items []json.RawMessage
+	next := func() error {
+		if len(items) == models.MaxBulkQuotes {
+			return errTooManyQuotes
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		items = append(items, raw)
+
+		return nil
+	}
+
+	if isNDJSON(r) {
+		for dec.More() {
+			if err := next(); err != nil {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	}
+
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, errors.New("expected a JSON array")
+	}
+
+	for dec.More() {
+		if err := next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON array")
+	}
+
+	return items, nil
+}
+
+func decodeBulkItem(raw json.RawMessage, q *models.Quote) []models.FieldError {
+	err := decode(json.NewDecoder(bytes.NewReader(raw)), q)
+
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return validationErr.Errors
+	case err != nil:
+		return []models.FieldError{{Message: "must be a quote object"}}
+	}
+
+	return nil
+}
+
+func isNDJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/x-ndjson" || mediaType == "application/ndjson"
+}
@@ -1,13 +1,54 @@
 package handlers
 
-import "github.com/odysseymorphey/quotes-service/internal/repository"
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+)
 
 type BaseHandler struct {
 	Repo repository.Repository
+	// Log gets the failures of requests. It is slog.Default when nil.
+	Log *slog.Logger
+	// Rotations keeps the rotations of GET /quotes/random clients. The
+	// client_id parameter is ignored when it is nil.
+	Rotations repository.RotationStore
+	// Now returns the current time. It is time.Now when nil.
+	Now func() time.Time
 }
 
-func New(r repository.Repository) *BaseHandler {
+func New(r repository.Repository, rs repository.RotationStore, l *slog.Logger) *BaseHandler {
 	return &BaseHandler{
-		Repo: r,
+		Repo:      r,
+		Log:       l,
+		Rotations: rs,
+	}
+}
+
+func (h *BaseHandler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+
+	return time.Now()
+}
+
+func (h *BaseHandler) log() *slog.Logger {
+	if h.Log != nil {
+		return h.Log
+	}
+
+	return slog.Default()
+}
+
+func (h *BaseHandler) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log().WarnContext(r.Context(), "JSON encoding error", slog.Any("error", err))
 	}
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+type validatable interface {
+	Normalize()
+	Validate() error
+}
+
+// decodeValid decodes the request body into v, then normalizes and validates
+// it. On failure it writes the response itself and returns false.
+func (h *BaseHandler) decodeValid(w http.ResponseWriter, r *http.Request, v validatable) bool {
+	err := decode(json.NewDecoder(r.Body), v)
+
+	var validationErr *models.ValidationError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr), errors.As(err, &maxBytesErr):
+		h.writeError(w, r, err, "")
+		return false
+	case err != nil:
+		h.log().InfoContext(r.Context(), "Failed request body decoding", slog.Any("error", err))
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// decode reads the only JSON value from dec into v, then normalizes and
+// validates it. Unknown fields are reported as a *models.ValidationError, and
+// anything after the value is an error.
+func decode(dec *json.Decoder, v validatable) error {
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		if field, ok := unknownField(err); ok {
+			return &models.ValidationError{Errors: []models.FieldError{
+				{Field: field, Message: "unknown field"},
+			}}
+		}
+
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after JSON value")
+		}
+
+		return err
+	}
+
+	v.Normalize()
+
+	return v.Validate()
+}
+
+// unknownField extracts the field name from the error json.Decoder returns
+// when DisallowUnknownFields is set. The error has no type of its own.
+func unknownField(err error) (string, bool) {
+	name, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+
+	field, err := strconv.Unquote(name)
+	if err != nil {
+		return name, true
+	}
+
+	return field, true
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
 )
 
@@ -9,8 +8,7 @@ func (h *BaseHandler) DeleteQuote(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
 	if err := h.Repo.DeleteQuote(r.Context(), id); err != nil {
-		log.Printf("Failed to delete quote: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		h.writeError(w, r, err, "Failed to delete quote")
 		return
 	}
 
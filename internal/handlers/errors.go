@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+)
+
+// writeError maps repository errors to responses. Errors that aren't caused
+// by the request are logged with msg and reported as 500. Requests that ran
+// out of time are reported as 503, so that clients know to retry.
+func (h *BaseHandler) writeError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	var validationErr *models.ValidationError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &validationErr):
+		h.writeJSON(w, r, http.StatusUnprocessableEntity, validationErr)
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, fmt.Sprintf("Request body is larger than %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, repository.ErrCanceled), errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		h.log().WarnContext(r.Context(), msg, slog.Any("error", err))
+		http.Error(w, "Request timed out", http.StatusServiceUnavailable)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Quote not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrConflict):
+		http.Error(w, "Conflict", http.StatusConflict)
+	case errors.Is(err, repository.ErrInvalid):
+		http.Error(w, "Unprocessable entity", http.StatusUnprocessableEntity)
+	default:
+		h.log().ErrorContext(r.Context(), msg, slog.Any("error", err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
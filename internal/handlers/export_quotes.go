@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+// quoteEncoder writes quotes to the response one at a time.
+type quoteEncoder interface {
+	Encode(q models.Quote) error
+	Flush() error
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e ndjsonEncoder) Encode(q models.Quote) error {
+	return e.enc.Encode(q)
+}
+
+func (e ndjsonEncoder) Flush() error {
+	return nil
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+// Encode writes tags as a JSON array, since a tag may contain any separator
+// that could be used to join them.
+func (e csvEncoder) Encode(q models.Quote) error {
+	var tags []byte
+	if len(q.Tags) > 0 {
+		var err error
+		if tags, err = json.Marshal(q.Tags); err != nil {
+			return err
+		}
+	}
+
+	return e.w.Write([]string{q.Id, q.Author, q.Quote, q.AuthorId, q.Language, string(tags)})
+}
+
+func (e csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ExportQuotes streams every quote straight from the repository to the
+// client, so memory use doesn't depend on the size of the table.
+func (h *BaseHandler) ExportQuotes(w http.ResponseWriter, r *http.Request) {
+	var contentType, filename string
+	var newEncoder func(io.Writer) (quoteEncoder, error)
+
+	switch r.URL.Query().Get("format") {
+	case "", "ndjson":
+		contentType, filename = "application/x-ndjson", "quotes.ndjson"
+		newEncoder = func(w io.Writer) (quoteEncoder, error) {
+			return ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+		}
+	case "csv":
+		contentType, filename = "text/csv; charset=utf-8", "quotes.csv"
+		newEncoder = func(w io.Writer) (quoteEncoder, error) {
+			cw := csv.NewWriter(w)
+			return csvEncoder{w: cw}, cw.Write([]string{"id", "author", "quote", "author_id", "language", "tags"})
+		}
+	default:
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	var enc quoteEncoder
+	start := func() error {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		enc, err = newEncoder(w)
+		return err
+	}
+
+	for quote, err := range h.Repo.AllQuotes(r.Context()) {
+		if err != nil {
+			if enc == nil {
+				h.writeError(w, r, err, "Can't export quotes")
+				return
+			}
+
+			// The status line is already sent, all we can do is stop.
+			h.log().WarnContext(r.Context(), "Quotes export interrupted", slog.Any("error", err))
+			return
+		}
+
+		if enc == nil {
+			if err = start(); err != nil {
+				h.log().WarnContext(r.Context(), "Quotes export interrupted", slog.Any("error", err))
+				return
+			}
+		}
+
+		if err = enc.Encode(quote); err != nil {
+			h.log().WarnContext(r.Context(), "Quotes export interrupted", slog.Any("error", err))
+			return
+		}
+	}
+
+	if enc == nil {
+		if err := start(); err != nil {
+			h.log().WarnContext(r.Context(), "Quotes export interrupted", slog.Any("error", err))
+			return
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		h.log().WarnContext(r.Context(), "Quotes export interrupted", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+)
+
+func (h *BaseHandler) GetAuthors(w http.ResponseWriter, r *http.Request) {
+	p, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.Repo.GetAuthors(r.Context(), p)
+	if err != nil {
+		h.writeError(w, r, err, "Can't get authors")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, page)
+}
+
+// GetAuthorQuotes lists the quotes of one author, paginated like GetQuotes.
+func (h *BaseHandler) GetAuthorQuotes(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	p, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err = h.Repo.GetAuthorByID(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Author not found", http.StatusNotFound)
+			return
+		}
+
+		h.writeError(w, r, err, "Can't get author")
+		return
+	}
+
+	page, err := h.Repo.GetQuotes(r.Context(), models.QuoteFilter{AuthorId: id}, p)
+	if err != nil {
+		h.writeError(w, r, err, "Can't get quotes")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, page)
+}
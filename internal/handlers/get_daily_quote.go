@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+)
+
+// GetDailyQuote responds with the quote of the current calendar day in the tz
+// time zone, UTC by default. Everyone gets the same quote on the same day as
+// long as the quotes don't change, see models.DailyIndex.
+func (h *BaseHandler) GetDailyQuote(w http.ResponseWriter, r *http.Request) {
+	loc := time.UTC
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			http.Error(w, errInvalidTz.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	count, err := h.Repo.CountQuotes(r.Context(), models.QuoteFilter{})
+	if err != nil {
+		h.writeError(w, r, err, "Can't get quote")
+		return
+	}
+
+	if count == 0 {
+		http.Error(w, "No quotes found", http.StatusNotFound)
+		return
+	}
+
+	i := models.DailyIndex(models.DayNumber(h.now().In(loc)), count)
+
+	quote, err := h.Repo.GetQuoteAt(r.Context(), models.QuoteFilter{}, i)
+	if err != nil {
+		// Quotes deleted since they were counted.
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "No quotes found", http.StatusNotFound)
+			return
+		}
+		h.writeError(w, r, err, "Can't get quote")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, quote)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// GetQuoteByID counts a view of the quote it responds with, see
+// models.WeightPopular. Failing to count doesn't fail the request, and HEAD
+// requests, such as those of link checkers, aren't counted.
+func (h *BaseHandler) GetQuoteByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	quote, err := h.Repo.GetQuoteByID(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Can't get quote")
+		return
+	}
+
+	if r.Method != http.MethodHead {
+		if err := h.Repo.ViewQuote(r.Context(), id); err != nil {
+			h.log().WarnContext(r.Context(), "Can't count view", slog.Any("error", err))
+		}
+	}
+
+	h.writeJSON(w, r, http.StatusOK, quote)
+}
@@ -1,37 +1,27 @@
 package handlers
 
 import (
-	"encoding/json"
-	"github.com/odysseymorphey/quotes-service/internal/models"
-	"log"
 	"net/http"
 )
 
 func (h *BaseHandler) GetQuotes(w http.ResponseWriter, r *http.Request) {
-	author := r.URL.Query().Get("author")
-
-	var quotes []models.Quote
-	var err error
-
-	if author != "" {
-		quotes, err = h.Repo.GetQuotesByAuthor(r.Context(), author)
-	} else {
-		quotes, err = h.Repo.GetQuotes(r.Context())
+	f, err := parseQuoteFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
+	p, err := parsePageRequest(r)
 	if err != nil {
-		log.Printf("Can't get quotes: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	if err = json.NewEncoder(w).Encode(quotes); err != nil {
-		log.Printf("JSON encoding error: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	page, err := h.Repo.GetQuotes(r.Context(), f, p)
+	if err != nil {
+		h.writeError(w, r, err, "Can't get quotes")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.writeJSON(w, r, http.StatusOK, page)
 }
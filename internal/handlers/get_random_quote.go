@@ -1,37 +1,131 @@
 package handlers
 
 import (
-	"database/sql"
-	"encoding/json"
+	"context"
 	"errors"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
 )
 
+type randomQuotesResponse struct {
+	Quotes []models.Quote `json:"quotes"`
+}
+
+// GetRandomQuote picks among the quotes that match the same filters as
+// GetQuotes. Without n it responds with a single quote; with n, with a list
+// of up to n distinct quotes. See parseRandomRequest for the other
+// parameters.
 func (h *BaseHandler) GetRandomQuote(w http.ResponseWriter, r *http.Request) {
-	quote, err := h.Repo.GetRandomQuote(r.Context())
+	f, err := parseQuoteFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, many, err := parseRandomRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, err := parseClientID(r, req)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quotes []models.Quote
+	if client != "" && h.Rotations != nil {
+		quotes, err = h.rotatedQuotes(r.Context(), client, f, req.N)
+	} else {
+		quotes, err = h.Repo.GetRandomQuote(r.Context(), f, req)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			http.Error(w, "No quotes found", http.StatusNotFound)
 			return
 		}
-		log.Printf("Can't get quote: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		h.writeError(w, r, err, "Can't get quote")
 		return
 	}
 
-	if quote == nil {
+	if len(quotes) == 0 {
 		http.Error(w, "No quotes found", http.StatusNotFound)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	if err = json.NewEncoder(w).Encode(quote); err != nil {
-		log.Printf("JSON encoding error: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if many {
+		h.writeJSON(w, r, http.StatusOK, randomQuotesResponse{Quotes: quotes})
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	h.writeJSON(w, r, http.StatusOK, quotes[0])
+}
+
+// rotatedQuotes returns the next up to n quotes that match f in the rotation
+// of client. A client rotates separately through the quotes of every filter.
+func (h *BaseHandler) rotatedQuotes(ctx context.Context, client string, f models.QuoteFilter, n int) ([]models.Quote, error) {
+	count, err := h.Repo.CountQuotes(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	n = min(n, count)
+
+	rotation, err := h.Rotations.NextRotation(ctx, rotationKey(client, f), n)
+	if err != nil {
+		return nil, err
+	}
+
+	var quotes []models.Quote
+	for _, i := range rotation.Indexes(n, count) {
+		quote, err := h.Repo.GetQuoteAt(ctx, f, i)
+		// Quotes deleted since they were counted are skipped.
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, *quote)
+	}
+
+	return quotes, nil
+}
+
+// rotationKey identifies the rotation of client through the quotes that match
+// f. Filters are hashed to keep keys short.
+func rotationKey(client string, f models.QuoteFilter) string {
+	v := url.Values{}
+	if f.Author != "" {
+		v.Set("author", f.Author)
+		v.Set("author_match", string(f.AuthorMatch))
+	}
+	if f.AuthorId != "" {
+		v.Set("author_id", f.AuthorId)
+	}
+	for _, tag := range f.Tags {
+		v.Add("tag", tag)
+	}
+	if f.Language != "" {
+		v.Set("language", f.Language)
+	}
+	if f.MaxLength > 0 {
+		v.Set("max_length", strconv.Itoa(f.MaxLength))
+	}
+
+	if len(v) == 0 {
+		return client
+	}
+
+	return client + "?" + strconv.FormatUint(models.ParseSeed(v.Encode()), 36)
 }
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+type tagsResponse struct {
+	Tags []models.Tag `json:"tags"`
+}
+
+func (h *BaseHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.Repo.GetTags(r.Context())
+	if err != nil {
+		h.writeError(w, r, err, "Can't get tags")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, tagsResponse{Tags: tags})
+}
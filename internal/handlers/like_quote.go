@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// LikeQuote counts a like of the quote, see models.WeightPopular.
+func (h *BaseHandler) LikeQuote(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.Repo.LikeQuote(r.Context(), id); err != nil {
+		h.writeError(w, r, err, "Failed to like quote")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
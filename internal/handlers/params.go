@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+var (
+	errInvalidLimit     = errors.New("invalid limit")
+	errInvalidMaxLength = errors.New("invalid max_length")
+	errInvalidN         = errors.New("invalid n")
+	errInvalidTz        = errors.New("invalid tz")
+	errWeightWithSeed   = errors.New("weight must be uniform with seed")
+	errInvalidClientID  = errors.New("invalid client_id")
+	errClientWithSeed   = errors.New("client_id can't be combined with seed or weight")
+)
+
+// maxRandomQuotes caps the n parameter of GET /quotes/random.
+const maxRandomQuotes = models.MaxPageLimit
+
+// maxClientIDLength caps the length of client ids in bytes.
+const maxClientIDLength = 64
+
+// parsePageRequest reads the limit and cursor query parameters.
+func parsePageRequest(r *http.Request) (models.PageRequest, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return models.PageRequest{}, err
+	}
+
+	p := models.PageRequest{Limit: limit}
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		after, err := models.DecodeCursor(cursor)
+		if err != nil {
+			return p, err
+		}
+		p.After = after
+	}
+
+	return p, nil
+}
+
+// parseQuoteFilter reads the author, author_match, tag, language and
+// max_length query parameters.
+func parseQuoteFilter(r *http.Request) (models.QuoteFilter, error) {
+	query := r.URL.Query()
+
+	match, err := models.ParseAuthorMatch(query.Get("author_match"))
+	if err != nil {
+		return models.QuoteFilter{}, err
+	}
+
+	f := models.QuoteFilter{
+		Author:      query.Get("author"),
+		AuthorMatch: match,
+		Tags:        parseTags(r),
+		Language:    models.NormalizeLanguage(query.Get("language")),
+	}
+
+	if maxLength := query.Get("max_length"); maxLength != "" {
+		n, err := strconv.Atoi(maxLength)
+		if err != nil || n < 1 {
+			return models.QuoteFilter{}, errInvalidMaxLength
+		}
+		f.MaxLength = n
+	}
+
+	return f, nil
+}
+
+// parseTags reads the repeatable tag query parameter. Tags are normalized the
+// same way as when quotes are stored.
+func parseTags(r *http.Request) []string {
+	return models.NormalizeTags(r.URL.Query()["tag"])
+}
+
+// parseN reads the n query parameter of GET /quotes/random. ok is false if it
+// is absent. Like limits, values above maxRandomQuotes are clamped.
+func parseN(r *http.Request) (n int, ok bool, err error) {
+	s := r.URL.Query().Get("n")
+	if s == "" {
+		return 1, false, nil
+	}
+
+	n, err = strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, true, errInvalidN
+	}
+
+	return min(n, maxRandomQuotes), true, nil
+}
+
+// parseLimit reads the limit query parameter. Limits above
+// models.MaxPageLimit are clamped rather than rejected.
+func parseLimit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return models.DefaultPageLimit, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, errInvalidLimit
+	}
+
+	return min(n, models.MaxPageLimit), nil
+}
+
+// parseRandomRequest reads the n, seed and weight query parameters of
+// GET /quotes/random. many is true if n is present. Seeded picks are only
+// reproducible with uniform weights, so other weights are rejected with seed.
+func parseRandomRequest(r *http.Request) (req models.RandomRequest, many bool, err error) {
+	query := r.URL.Query()
+
+	if req.N, many, err = parseN(r); err != nil {
+		return req, many, err
+	}
+
+	if req.Weight, err = models.ParseWeight(query.Get("weight")); err != nil {
+		return req, many, err
+	}
+
+	if seed := query.Get("seed"); seed != "" {
+		if req.Weight != models.WeightUniform {
+			return req, many, errWeightWithSeed
+		}
+
+		s := models.ParseSeed(seed)
+		req.Seed = &s
+	}
+
+	return req, many, nil
+}
+
+// parseClientID reads the client id of GET /quotes/random from the client_id
+// query parameter or, failing that, the client_id cookie. It is empty if there
+// is none. A rotation is neither seeded nor weighted, so req can't be either.
+func parseClientID(r *http.Request, req models.RandomRequest) (string, error) {
+	client := r.URL.Query().Get("client_id")
+	if client == "" {
+		if cookie, err := r.Cookie("client_id"); err == nil {
+			client = cookie.Value
+		}
+	}
+
+	if client == "" {
+		return "", nil
+	}
+
+	if len(client) > maxClientIDLength {
+		return "", errInvalidClientID
+	}
+
+	if req.Seed != nil || req.Weight != models.WeightUniform {
+		return "", errClientWithSeed
+	}
+
+	return client, nil
+}
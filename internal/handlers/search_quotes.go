@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+type searchResponse struct {
+	Results []models.SearchResult `json:"results"`
+}
+
+func (h *BaseHandler) SearchQuotes(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.Repo.SearchQuotes(r.Context(), query, limit)
+	if err != nil {
+		h.writeError(w, r, err, "Can't search quotes")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, searchResponse{Results: results})
+}
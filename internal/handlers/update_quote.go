@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"net/http"
+)
+
+func (h *BaseHandler) UpdateQuote(w http.ResponseWriter, r *http.Request) {
+	var quote models.Quote
+	if !h.decodeValid(w, r, &quote) {
+		return
+	}
+
+	quote.Id = r.PathValue("id")
+
+	updated, err := h.Repo.UpdateQuote(r.Context(), quote)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to update quote")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, updated)
+}
+
+func (h *BaseHandler) PatchQuote(w http.ResponseWriter, r *http.Request) {
+	var patch models.QuotePatch
+	if !h.decodeValid(w, r, &patch) {
+		return
+	}
+
+	updated, err := h.Repo.PatchQuote(r.Context(), r.PathValue("id"), patch)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to patch quote")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, updated)
+}
@@ -0,0 +1,78 @@
+// Package logging sets up the structured logger of the service and tags the
+// records logged while serving a request with that request.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Formats of New.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Request is what the records logged while serving a request are tagged with.
+// It is shared by pointer, so that a field filled in deep in the middleware
+// chain, such as the route, is seen by the access log around it.
+type Request struct {
+	ID    string
+	Route string
+}
+
+type requestKey struct{}
+
+// WithRequest returns a copy of ctx carrying req.
+func WithRequest(ctx context.Context, req *Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFrom returns the request in ctx, or nil if there is none.
+func RequestFrom(ctx context.Context) *Request {
+	req, _ := ctx.Value(requestKey{}).(*Request)
+	return req
+}
+
+// New returns a logger writing records of level and above to w, as JSON or
+// as text. Records logged with a context carrying a Request get its ID and
+// route.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if format == FormatText {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{h})
+}
+
+// contextHandler adds the request in the context of a record to it.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if req := RequestFrom(ctx); req != nil {
+		if req.ID != "" {
+			r.AddAttrs(slog.String("request_id", req.ID))
+		}
+		if req.Route != "" {
+			r.AddAttrs(slog.String("route", req.Route))
+		}
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
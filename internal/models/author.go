@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Author groups the quotes whose author names are spelling variants of one
+// another. Name is the spelling the author was first stored with.
+type Author struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	QuoteCount int    `json:"quote_count"`
+}
+
+type AuthorPage struct {
+	Authors    []Author `json:"authors"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// NewAuthorPage builds a page from authors fetched in id order with limit+1
+// rows, like NewPage.
+func NewAuthorPage(authors []Author, limit int) *AuthorPage {
+	if authors == nil {
+		authors = []Author{}
+	}
+
+	page := &AuthorPage{Authors: authors}
+	if len(authors) > limit {
+		page.Authors = authors[:limit]
+		page.NextCursor = EncodeCursor(authors[limit-1].Id)
+	}
+
+	return page
+}
+
+// AuthorKey folds spelling variants of an author name to the same key. Case,
+// punctuation and spacing are ignored and "ё" is read as "е", so "Лев Толстой",
+// "лев  толстой" and "Лёв Толстой." share a key.
+func AuthorKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return strings.ToLower(name)
+	}
+
+	return strings.ReplaceAll(strings.Join(words, " "), "ё", "е")
+}
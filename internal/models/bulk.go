@@ -0,0 +1,23 @@
+package models
+
+// MaxBulkQuotes caps the number of quotes accepted by a single bulk import.
+const MaxBulkQuotes = 10000
+
+const (
+	BulkStatusCreated = "created"
+	BulkStatusInvalid = "invalid"
+	BulkStatusFailed  = "failed"
+)
+
+// BulkItemResult reports what happened to the quote at Index of an import.
+type BulkItemResult struct {
+	Index  int          `json:"index"`
+	Status string       `json:"status"`
+	Quote  *Quote       `json:"quote,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type BulkResult struct {
+	Created int              `json:"created"`
+	Results []BulkItemResult `json:"results,omitempty"`
+}
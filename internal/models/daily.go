@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DayNumber returns the number of days from 1970-01-01 to the calendar date of
+// t in its own location.
+func DayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+}
+
+// DailyIndex returns the position among n quotes of the quote of the given
+// day. Every n days make up a round that goes through a different shuffle of
+// all n positions, so no quote repeats until all of them have been shown, and
+// the same quote is never the quote of two days in a row.
+func DailyIndex(day, n int) int {
+	round, i := day/n, day%n
+	if i < 0 {
+		round, i = round-1, i+n
+	}
+
+	return roundIndex(0, round, i, n)
+}
@@ -0,0 +1,48 @@
+package models
+
+import "errors"
+
+// AuthorMatch selects how QuoteFilter.Author is compared with quote authors.
+type AuthorMatch string
+
+const (
+	// AuthorExact matches the whole author, case included. It is the default.
+	AuthorExact AuthorMatch = "exact"
+	// AuthorIExact matches the whole author ignoring case.
+	AuthorIExact AuthorMatch = "iexact"
+	// AuthorPrefix matches authors that start with the given text, ignoring case.
+	AuthorPrefix AuthorMatch = "prefix"
+	// AuthorContains matches authors that contain the given text, ignoring case.
+	AuthorContains AuthorMatch = "contains"
+)
+
+var ErrInvalidAuthorMatch = errors.New("invalid author_match")
+
+// ParseAuthorMatch parses an author_match query parameter. An empty string
+// means AuthorExact.
+func ParseAuthorMatch(s string) (AuthorMatch, error) {
+	switch m := AuthorMatch(s); m {
+	case "":
+		return AuthorExact, nil
+	case AuthorExact, AuthorIExact, AuthorPrefix, AuthorContains:
+		return m, nil
+	}
+
+	return "", ErrInvalidAuthorMatch
+}
+
+// QuoteFilter narrows down a quote listing. The zero value matches every quote.
+type QuoteFilter struct {
+	// Author is ignored when empty.
+	Author      string
+	AuthorMatch AuthorMatch
+	// AuthorId is ignored when empty.
+	AuthorId string
+	// Tags, normalized, must all be set on a quote for it to match.
+	Tags []string
+	// Language, normalized, is ignored when empty.
+	Language string
+	// MaxLength limits the quote text length in characters. It is ignored
+	// when zero.
+	MaxLength int
+}
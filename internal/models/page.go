@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest selects quotes ordered by id. After is the id of the last quote
+// on the previous page, zero for the first page.
+type PageRequest struct {
+	After int64
+	Limit int
+}
+
+type Page struct {
+	Quotes     []Quote `json:"quotes"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// NewPage builds a page from quotes fetched in id order with limit+1 rows.
+// The extra row is only used to tell whether a next page exists.
+func NewPage(quotes []Quote, limit int) *Page {
+	if quotes == nil {
+		quotes = []Quote{}
+	}
+
+	page := &Page{Quotes: quotes}
+	if len(quotes) > limit {
+		page.Quotes = quotes[:limit]
+		page.NextCursor = EncodeCursor(quotes[limit-1].Id)
+	}
+
+	return page
+}
+
+func EncodeCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+func DecodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
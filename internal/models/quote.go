@@ -4,4 +4,27 @@ type Quote struct {
 	Id     string `json:"id"`
 	Author string `json:"author"`
 	Quote  string `json:"quote"`
+	// AuthorId is set by the storage and ignored on input.
+	AuthorId string `json:"author_id,omitempty"`
+	// Language is an optional ISO 639 code, such as "en" or "ru".
+	Language string   `json:"language,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// QuotePatch holds a partial update of a quote. Nil fields are left unchanged.
+type QuotePatch struct {
+	Author *string `json:"author"`
+	Quote  *string `json:"quote"`
+	// Language is cleared by an empty string.
+	Language *string `json:"language"`
+	// Tags replaces all tags of the quote; an empty list removes them.
+	Tags *[]string `json:"tags"`
+}
+
+// SearchResult is a quote found by full-text search. Snippet is the quote
+// text, HTML-escaped, with the matching words wrapped in <mark> tags.
+type SearchResult struct {
+	Quote
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
 }
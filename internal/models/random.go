@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+// Weight selects how likely each quote is to be picked by GetRandomQuote.
+// Weighted picks give every matching quote the key -ln(u)/weight for a
+// uniform u and keep the ones with the smallest keys (Efraimidis and
+// Spirakis), which also puts them in random order.
+type Weight string
+
+const (
+	// WeightUniform gives every quote the same chance. It is the default.
+	WeightUniform Weight = "uniform"
+	// WeightPopular makes the chance of a quote proportional to
+	// PopularWeight of its views and likes.
+	WeightPopular Weight = "popular"
+	// WeightRecent makes the chance of a quote proportional to its id, which
+	// grows with every quote added, so newer quotes are favoured.
+	WeightRecent Weight = "recent"
+)
+
+// LikeViews is how many views a like counts as in PopularWeight.
+const LikeViews = 10
+
+var ErrInvalidWeight = errors.New("invalid weight")
+
+// ParseWeight parses a weight query parameter. An empty string means
+// WeightUniform.
+func ParseWeight(s string) (Weight, error) {
+	switch w := Weight(s); w {
+	case "":
+		return WeightUniform, nil
+	case WeightUniform, WeightPopular, WeightRecent:
+		return w, nil
+	}
+
+	return "", ErrInvalidWeight
+}
+
+// PopularWeight is the weight of a quote with WeightPopular. The one keeps
+// quotes nobody has seen yet in the draw.
+func PopularWeight(views, likes int64) float64 {
+	return float64(1 + views + LikeViews*likes)
+}
+
+// RandomRequest selects how many quotes GetRandomQuote picks and how.
+type RandomRequest struct {
+	// N is how many distinct quotes to pick.
+	N int
+	// Seed makes the pick reproducible when not nil: the same seed picks the
+	// same quotes in the same order as long as the matching quotes don't
+	// change. Weight is ignored with a seed.
+	Seed *uint64
+	// Weight is WeightUniform when empty.
+	Weight Weight
+}
+
+// ParseSeed turns a seed query parameter into a RandomRequest.Seed, so that
+// any text can be used as a seed.
+func ParseSeed(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// SeededIndexes returns the indexes among count quotes in id order of the
+// quotes picked with seed, up to n of them, in the order they are picked.
+func SeededIndexes(seed uint64, n, count int) []int {
+	indexes := make([]int, min(n, count))
+	for i := range indexes {
+		indexes[i] = Permute(seed, i, count)
+	}
+
+	return indexes
+}
+
+// PickIndexed returns the quotes in byIndex, keyed by their indexes among the
+// matching quotes, in the order of indexes from SeededIndexes. Indexes without
+// a quote, which are only missing if quotes were deleted after they were
+// counted, are skipped.
+func PickIndexed(byIndex map[int]Quote, indexes []int) []Quote {
+	quotes := make([]Quote, 0, len(byIndex))
+	for _, i := range indexes {
+		if quote, ok := byIndex[i]; ok {
+			quotes = append(quotes, quote)
+		}
+	}
+
+	return quotes
+}
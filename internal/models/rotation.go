@@ -0,0 +1,31 @@
+package models
+
+// Rotation is where a client is in its walk through the quotes: every count
+// positions make up a round that goes through a different shuffle of all of
+// them, picked with Seed, so no quote repeats until all of them have been
+// shown.
+type Rotation struct {
+	Seed     uint64
+	Position int
+}
+
+// Indexes returns the indexes among count quotes of the next quotes from
+// r.Position on, up to n of them without repeats.
+func (r Rotation) Indexes(n, count int) []int {
+	seen := make(map[int]bool, min(n, count))
+
+	var indexes []int
+	for pos := r.Position; pos < r.Position+min(n, count); pos++ {
+		if i := r.index(pos, count); !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// index returns the index of the quote at pos, see roundIndex.
+func (r Rotation) index(pos, count int) int {
+	return roundIndex(r.Seed, pos/count, pos%count, count)
+}
@@ -0,0 +1,55 @@
+package models
+
+// Permute maps position i in [0, n) to its place in a shuffle of all n
+// positions chosen by seed. The same seed always gives the same shuffle, and
+// going through i = 0..n-1 visits every position once. It is a Feistel network
+// over the smallest power of four that holds n, walking the cycle until it
+// lands back in [0, n), so no shuffle has to be stored.
+func Permute(seed uint64, i, n int) int {
+	half := 1
+	for 1<<(2*half) < n {
+		half++
+	}
+	mask := uint64(1)<<half - 1
+
+	x := uint64(i)
+	for {
+		l, r := x>>half, x&mask
+		for round := range uint64(4) {
+			l, r = r, l^(mix(seed^round<<56^r)&mask)
+		}
+
+		if x = l<<half | r; x < uint64(n) {
+			return int(x)
+		}
+	}
+}
+
+// roundIndex returns the position among n of the i-th one in the given round
+// of an endless walk where every round is the shuffle Permute(seed+round). When
+// a round would start with the position the previous one ended with, its first
+// two are swapped, so the same position never comes twice in a row. With two
+// positions that leaves every round the same as the first one, as alternating
+// is the only order without repeats.
+func roundIndex(seed uint64, round, i, n int) int {
+	if n == 2 {
+		return Permute(seed, i, 2)
+	}
+
+	// The last position of a round is never swapped, so it can be compared
+	// with directly.
+	if n > 2 && i < 2 &&
+		Permute(seed+uint64(round), 0, n) == Permute(seed+uint64(round-1), n-1, n) {
+		i = 1 - i
+	}
+
+	return Permute(seed+uint64(round), i, n)
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
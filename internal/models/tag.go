@@ -0,0 +1,32 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+type Tag struct {
+	Name       string `json:"name"`
+	QuoteCount int    `json:"quote_count"`
+}
+
+// NormalizeTag lowercases tag and collapses whitespace in it, so "Stoicism"
+// and " stoicism " are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags normalizes every tag, drops empty ones and duplicates, and
+// sorts the rest. It returns nil rather than an empty slice.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxAuthorLength matches the author VARCHAR(30) column.
+	MaxAuthorLength = 30
+	// MaxQuoteLength caps the quote TEXT column, which has no limit of its own.
+	MaxQuoteLength = 2000
+	// MaxTagLength matches the tag name VARCHAR(30) column.
+	MaxTagLength = 30
+	MaxTags      = 10
+)
+
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found in a submitted value.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// err returns nil if no problems were recorded, so callers don't end up with
+// a non-nil error interface holding an empty ValidationError.
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Normalize trims surrounding whitespace and collapses whitespace runs in the
+// author name. Tags are normalized with NormalizeTags.
+func (q *Quote) Normalize() {
+	q.Author = normalizeAuthor(q.Author)
+	q.Quote = strings.TrimSpace(q.Quote)
+	q.Language = NormalizeLanguage(q.Language)
+	q.Tags = NormalizeTags(q.Tags)
+}
+
+func (q *Quote) Validate() error {
+	var v ValidationError
+
+	validateAuthor(&v, q.Author)
+	validateQuote(&v, q.Quote)
+	validateLanguage(&v, q.Language)
+	validateTags(&v, q.Tags)
+
+	return v.err()
+}
+
+func (p *QuotePatch) Normalize() {
+	if p.Author != nil {
+		author := normalizeAuthor(*p.Author)
+		p.Author = &author
+	}
+	if p.Quote != nil {
+		quote := strings.TrimSpace(*p.Quote)
+		p.Quote = &quote
+	}
+	if p.Language != nil {
+		language := NormalizeLanguage(*p.Language)
+		p.Language = &language
+	}
+	if p.Tags != nil {
+		tags := NormalizeTags(*p.Tags)
+		p.Tags = &tags
+	}
+}
+
+// Validate checks the fields that are set. A patch must set at least one.
+func (p *QuotePatch) Validate() error {
+	var v ValidationError
+
+	if p.Author == nil && p.Quote == nil && p.Language == nil && p.Tags == nil {
+		v.add("", "at least one of author, quote, language, tags is required")
+	}
+	if p.Author != nil {
+		validateAuthor(&v, *p.Author)
+	}
+	if p.Quote != nil {
+		validateQuote(&v, *p.Quote)
+	}
+	if p.Language != nil {
+		validateLanguage(&v, *p.Language)
+	}
+	if p.Tags != nil {
+		validateTags(&v, *p.Tags)
+	}
+
+	return v.err()
+}
+
+func normalizeAuthor(author string) string {
+	return strings.Join(strings.Fields(author), " ")
+}
+
+func validateAuthor(v *ValidationError, author string) {
+	switch {
+	case author == "":
+		v.add("author", "is required")
+	case utf8.RuneCountInString(author) > MaxAuthorLength:
+		v.add("author", fmt.Sprintf("must be at most %d characters", MaxAuthorLength))
+	}
+}
+
+func validateQuote(v *ValidationError, quote string) {
+	switch {
+	case quote == "":
+		v.add("quote", "is required")
+	case utf8.RuneCountInString(quote) > MaxQuoteLength:
+		v.add("quote", fmt.Sprintf("must be at most %d characters", MaxQuoteLength))
+	}
+}
+
+// NormalizeLanguage trims and lowercases a language code, so "EN" and "en"
+// are the same language.
+func NormalizeLanguage(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// validateLanguage accepts an empty language, which means it is unknown.
+func validateLanguage(v *ValidationError, language string) {
+	if language != "" && !languageCode.MatchString(language) {
+		v.add("language", "must be a two or three letter ISO 639 code")
+	}
+}
+
+// validateTags expects tags that went through NormalizeTags, which drops
+// empty ones.
+func validateTags(v *ValidationError, tags []string) {
+	if len(tags) > MaxTags {
+		v.add("tags", fmt.Sprintf("must have at most %d items", MaxTags))
+	}
+
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			v.add("tags", fmt.Sprintf("%q must be at most %d characters", tag, MaxTagLength))
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "errors"
+
+// Errors returned by Repository implementations. Backends wrap them together
+// with the underlying driver error, so callers should match with errors.Is.
+var (
+	// ErrNotFound means the requested quote doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the change clashes with data already stored.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalid means the storage rejected the data, e.g. a value is too long.
+	ErrInvalid = errors.New("invalid")
+	// ErrCanceled means the storage gave up on a query because its context
+	// was canceled or ran past its deadline.
+	ErrCanceled = errors.New("canceled")
+)
@@ -3,13 +3,63 @@ package repository
 import (
 	"context"
 	"github.com/odysseymorphey/quotes-service/internal/models"
+	"iter"
 )
 
 type Repository interface {
-	AddQuote(ctx context.Context, q models.Quote) error
-	GetQuotes(ctx context.Context) ([]models.Quote, error)
-	GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error)
-	GetRandomQuote(ctx context.Context) (*models.Quote, error)
+	// AddQuote links the quote to the author with the same models.AuthorKey,
+	// creating the author and the missing tags if there are none yet.
+	AddQuote(ctx context.Context, q models.Quote) (*models.Quote, error)
+	// AddQuotes stores all quotes in a single transaction and returns how
+	// many were stored.
+	AddQuotes(ctx context.Context, quotes []models.Quote) (int, error)
+	// GetQuotes returns a page of the quotes that match f, in id order.
+	GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (*models.Page, error)
+	// AllQuotes iterates over every quote in id order without loading them
+	// all into memory. Iteration stops after the first error.
+	AllQuotes(ctx context.Context) iter.Seq2[models.Quote, error]
+	GetQuoteByID(ctx context.Context, id string) (*models.Quote, error)
+	// SearchQuotes returns up to limit quotes whose text matches query, most
+	// relevant first.
+	SearchQuotes(ctx context.Context, query string, limit int) ([]models.SearchResult, error)
+	// GetRandomQuote returns up to r.N distinct quotes picked at random among
+	// the ones that match f, in random order, with chances set by r.Weight.
+	// With r.Seed the quotes are the ones models.SeededIndexes picks. It
+	// returns ErrNotFound if no quote matches.
+	GetRandomQuote(ctx context.Context, f models.QuoteFilter, r models.RandomRequest) ([]models.Quote, error)
+	// CountQuotes returns how many quotes match f.
+	CountQuotes(ctx context.Context, f models.QuoteFilter) (int, error)
+	// GetQuoteAt returns the quote at index i, from zero, among the ones that
+	// match f in id order. It returns ErrNotFound if there are not that many.
+	GetQuoteAt(ctx context.Context, f models.QuoteFilter, i int) (*models.Quote, error)
+	// ViewQuote and LikeQuote add one to the views or likes of the quote with
+	// id, which weight models.WeightPopular picks. They return ErrNotFound if
+	// there is no such quote.
+	ViewQuote(ctx context.Context, id string) error
+	LikeQuote(ctx context.Context, id string) error
+	// UpdateQuote replaces the author, text, language and tags of the quote
+	// with q.Id, and PatchQuote only the fields set in p. Both link the quote
+	// to its author like AddQuote and return ErrNotFound if there is no such
+	// quote.
+	UpdateQuote(ctx context.Context, q models.Quote) (*models.Quote, error)
+	PatchQuote(ctx context.Context, id string, p models.QuotePatch) (*models.Quote, error)
 	DeleteQuote(ctx context.Context, id string) error
+	// GetAuthors returns a page of the authors that have quotes, in id order.
+	GetAuthors(ctx context.Context, p models.PageRequest) (*models.AuthorPage, error)
+	// GetAuthorByID returns ErrNotFound for authors left without quotes, the
+	// same as GetAuthors skips them.
+	GetAuthorByID(ctx context.Context, id string) (*models.Author, error)
+	// GetTags returns the tags that have quotes with their quote counts, the
+	// most used first.
+	GetTags(ctx context.Context) ([]models.Tag, error)
 	Close() error
 }
+
+// RotationStore keeps the rotations of clients through the quotes, see
+// models.Rotation. Implementations must be safe for concurrent use.
+type RotationStore interface {
+	// NextRotation returns the rotation of client and moves its position n
+	// quotes ahead. A client it doesn't know yet starts at position zero with
+	// a random seed.
+	NextRotation(ctx context.Context, client string, n int) (models.Rotation, error)
+}
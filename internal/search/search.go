@@ -0,0 +1,106 @@
+// Package search implements the full-text matching used by storage backends
+// that have no text search of their own. It mirrors the Postgres "simple"
+// configuration: text is split into lowercase words without stemming, and a
+// quote matches when it contains every word of the query.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	markStart = "<mark>"
+	markEnd   = "</mark>"
+)
+
+// Terms splits query into unique lowercase words.
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	for _, word := range words(query) {
+		word = strings.ToLower(word)
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
+
+// Rank scores text against terms by the share of its words that match. It
+// returns zero unless every term occurs in text.
+func Rank(text string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(terms))
+	for _, term := range terms {
+		counts[term] = 0
+	}
+
+	ws := words(text)
+	matched := 0
+	for _, word := range ws {
+		word = strings.ToLower(word)
+		if _, ok := counts[word]; ok {
+			counts[word]++
+			matched++
+		}
+	}
+
+	for _, n := range counts {
+		if n == 0 {
+			return 0
+		}
+	}
+
+	return float64(matched) / float64(len(ws))
+}
+
+// Highlight HTML-escapes text and wraps the words matching terms in <mark>
+// tags.
+func Highlight(text string, terms []string) string {
+	match := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		match[term] = true
+	}
+
+	var b strings.Builder
+	for len(text) > 0 {
+		i := strings.IndexFunc(text, isWordRune)
+		if i < 0 {
+			b.WriteString(html.EscapeString(text))
+			break
+		}
+		b.WriteString(html.EscapeString(text[:i]))
+		text = text[i:]
+
+		j := strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) })
+		if j < 0 {
+			j = len(text)
+		}
+
+		word := text[:j]
+		if match[strings.ToLower(word)] {
+			b.WriteString(markStart + html.EscapeString(word) + markEnd)
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		text = text[j:]
+	}
+
+	return b.String()
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
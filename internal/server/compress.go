@@ -0,0 +1,157 @@
+package server
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressible are the content types worth compressing. Everything the API
+// sends is text, but error pages of other middlewares might not be.
+var compressible = []string{"application/json", "application/x-ndjson", "text/"}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Compress gzips responses for clients that accept it. The choice is made
+// when the handler sends the status line, from the content type it set, so
+// streamed responses such as exports are compressed as they go and Flush
+// still reaches the client.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, which
+// it doesn't if gzip is listed with q=0.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if c := strings.TrimSpace(coding); c != "gzip" && c != "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		return q > 0
+	}
+
+	return false
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if !w.decided && status >= 200 {
+		w.decided = true
+		if w.compress(status) {
+			h := w.Header()
+			h.Set("Content-Encoding", "gzip")
+			h.Del("Content-Length")
+
+			w.gz = gzipWriters.Get().(*gzip.Writer)
+			w.gz.Reset(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) compress(status int) bool {
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		// The handler left it to net/http to sniff, which can't see
+		// through compression. Error pages are plain text.
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	for _, c := range compressible {
+		if mediaType == c || strings.HasSuffix(c, "/") && strings.HasPrefix(mediaType, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+
+	return w.gz.Write(b)
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the deadlines of the
+// connection. Flush is implemented above, so that flushes go through the
+// compressor.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close ends the gzip stream and returns the writer to the pool.
+func (w *gzipResponseWriter) close() {
+	if w.gz == nil {
+		return
+	}
+
+	if err := w.gz.Close(); err == nil {
+		w.gz.Reset(nil)
+		gzipWriters.Put(w.gz)
+	}
+	w.gz = nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	corsMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	corsHeaders = []string{"Content-Type", RequestIDHeader}
+	// corsExposed are the response headers scripts may read besides the
+	// CORS-safelisted ones.
+	corsExposed = []string{"Location", RequestIDHeader}
+)
+
+// corsMaxAge is how long browsers may cache a preflight response.
+const corsMaxAge = 10 * time.Minute
+
+// CORS lets browser scripts from origins call the API. "*" allows every
+// origin. Preflight requests from allowed origins are answered here, before
+// they reach the routes, which don't know about OPTIONS. Without origins the
+// middleware does nothing.
+func CORS(origins []string) Middleware {
+	all := slices.Contains(origins, "*")
+
+	allowed := func(origin string) bool {
+		return all || slices.Contains(origins, origin)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if !all {
+				// The response differs between origins, so caches
+				// must tell them apart.
+				w.Header().Add("Vary", "Origin")
+			}
+			if origin == "" || !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if all {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+				h.Set("Access-Control-Allow-Headers", strings.Join(corsHeaders, ", "))
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Set("Access-Control-Expose-Headers", strings.Join(corsExposed, ", "))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
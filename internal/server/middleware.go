@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+)
+
+// Middleware wraps a handler with behaviour shared by several routes.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with ms. The first middleware is the outermost one: it sees
+// the request first and the response last.
+func Chain(h http.Handler, ms ...Middleware) http.Handler {
+	for i := len(ms) - 1; i >= 0; i-- {
+		h = ms[i](h)
+	}
+
+	return h
+}
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps request IDs taken from clients, which end up in
+// every log line of the request.
+const maxRequestIDLength = 128
+
+// RequestIDFrom returns the ID the RequestID middleware gave the request, or
+// "" outside of it.
+func RequestIDFrom(ctx context.Context) string {
+	if req := logging.RequestFrom(ctx); req != nil {
+		return req.ID
+	}
+
+	return ""
+}
+
+// RequestID tags every request with an ID, kept from the X-Request-ID header
+// when the client sent a usable one, so that a request can be followed through
+// a proxy into the logs. The ID is echoed in the response, and every record
+// logged with the request context carries it.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := logging.WithRequest(r.Context(), &logging.Request{ID: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Route records the pattern a request matched, for the logs. It goes after
+// RequestID.
+func Route(pattern string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if req := logging.RequestFrom(r.Context()); req != nil {
+				req.Route = pattern
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validRequestID accepts printable ASCII only, so that an ID can't forge log
+// lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
+
+// Recover turns a panicking handler into a 500 response instead of a dropped
+// connection, and logs the panic with its stack. http.ErrAbortHandler is left
+// to net/http, which uses it to abort a response on purpose.
+func Recover(l *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := wrapResponseWriter(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+
+				l.ErrorContext(r.Context(), "Panic serving request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("panic", v),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				// Once the status line is out, all that is left is to cut
+				// the response short.
+				if rw.status != 0 {
+					panic(http.ErrAbortHandler)
+				}
+				http.Error(rw, "Internal server error", http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// AccessLog logs every request once it is served, with its status, response
+// size and latency. Server errors are logged at error level.
+func AccessLog(l *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := wrapResponseWriter(w)
+
+			defer func() {
+				status := rw.statusCode()
+				level := slog.LevelInfo
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+
+				l.LogAttrs(r.Context(), level, "Request served",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.RequestURI()),
+					slog.Int("status", status),
+					slog.Int64("bytes", rw.bytes),
+					slog.Duration("latency", time.Since(start)),
+				)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// Timeout sets the deadline of the request context, which handlers pass on to
+// the repository. Handlers report a query cut short by it as 503.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// MaxBytes caps the request body. Reading past n fails with
+// *http.MaxBytesError, which handlers report as 413.
+func MaxBytes(n int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// responseWriter records the status and the size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// wrapResponseWriter reuses w if it already records the response, so that
+// stacked middlewares share one record.
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational responses are followed by the real one.
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+
+	return n, err
+}
+
+// statusCode returns the status sent, which is 200 if the handler wrote
+// nothing at all.
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// Unwrap lets http.ResponseController reach the Flusher and the deadlines of
+// the connection.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
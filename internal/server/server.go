@@ -2,49 +2,125 @@ package server
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 
+	"github.com/odysseymorphey/quotes-service/internal/config"
 	"github.com/odysseymorphey/quotes-service/internal/handlers"
 	"github.com/odysseymorphey/quotes-service/internal/repository"
 )
 
 type Server struct {
 	srv  *http.Server
+	tls  config.TLS
 	repo repository.Repository
 }
 
-func New(r repository.Repository) *Server {
+func New(cfg config.Server, r repository.Repository, rs repository.RotationStore, l *slog.Logger) *Server {
 	m := http.NewServeMux()
-	h := handlers.New(r)
+	h := handlers.New(r, rs, l)
 
-	registerRoutes(m, h)
+	registerRoutes(m, h, cfg)
 
 	return &Server{
 		srv: &http.Server{
-			Addr:    ":8080",
-			Handler: m,
+			Addr: cfg.Addr,
+			// The request ID comes first so that everything after it can
+			// log it, and the access log wraps recovery to log the 500 of
+			// a panic.
+			Handler: Chain(m,
+				RequestID,
+				AccessLog(l),
+				Recover(l),
+				CORS(cfg.CORSOrigins),
+				Compress,
+			),
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			IdleTimeout:    cfg.IdleTimeout,
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+			ErrorLog:       slog.NewLogLogger(l.Handler(), slog.LevelWarn),
 		},
+		tls:  cfg.TLS,
 		repo: r,
 	}
 }
 
-func registerRoutes(mux *http.ServeMux, h *handlers.BaseHandler) {
-	mux.HandleFunc("POST /quotes", h.AddQuote)
+func registerRoutes(mux *http.ServeMux, h *handlers.BaseHandler, cfg config.Server) {
+	std := []Middleware{Timeout(cfg.RequestTimeout), MaxBytes(cfg.MaxBodyBytes)}
+	// Imports and exports move the whole table at once.
+	bulk := []Middleware{Timeout(cfg.BulkRequestTimeout), MaxBytes(cfg.MaxBulkBodyBytes)}
 
-	mux.HandleFunc("GET /quotes", h.GetQuotes)
-	mux.HandleFunc("GET /quotes/random", h.GetRandomQuote)
+	handle := func(pattern string, ms []Middleware, f http.HandlerFunc) {
+		mux.Handle(pattern, Chain(f, append([]Middleware{Route(pattern)}, ms...)...))
+	}
+
+	handle("POST /quotes", std, h.AddQuote)
+	handle("POST /quotes/bulk", bulk, h.AddQuotesBulk)
+	handle("POST /quotes/{id}/like", std, h.LikeQuote)
+
+	handle("GET /quotes", std, h.GetQuotes)
+	handle("GET /quotes/random", std, h.GetRandomQuote)
+	handle("GET /quotes/daily", std, h.GetDailyQuote)
+	handle("GET /quotes/export", bulk, h.ExportQuotes)
+	handle("GET /quotes/search", std, h.SearchQuotes)
+	handle("GET /quotes/{id}", std, h.GetQuoteByID)
+
+	handle("PUT /quotes/{id}", std, h.UpdateQuote)
+	handle("PATCH /quotes/{id}", std, h.PatchQuote)
+
+	handle("DELETE /quotes/{id}", std, h.DeleteQuote)
 
-	mux.HandleFunc("DELETE /quotes/{id}", h.DeleteQuote)
+	handle("GET /authors", std, h.GetAuthors)
+	handle("GET /authors/{id}/quotes", std, h.GetAuthorQuotes)
+
+	handle("GET /tags", std, h.GetTags)
 }
 
-func (s *Server) Run() {
-	if err := s.srv.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+// Run listens on the configured address and serves until Stop is called.
+func (s *Server) Run() error {
+	l, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
 	}
+
+	return s.Serve(l)
 }
 
-func (s *Server) Stop() {
-	log.Println(s.repo.Close())
-	log.Println(s.srv.Shutdown(context.Background()))
+// Serve serves on l until Stop is called, after which it returns nil.
+func (s *Server) Serve(l net.Listener) error {
+	var err error
+	if s.tls.Enabled() {
+		err = s.srv.ServeTLS(l, s.tls.CertFile, s.tls.KeyFile)
+	} else {
+		err = s.srv.Serve(l)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Stop stops accepting connections and waits for in-flight requests until ctx
+// is done, after which it closes the connections still open. The repository
+// is closed last, so that no request can reach it closed.
+func (s *Server) Stop(ctx context.Context) error {
+	var errs []error
+	if err := s.srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain connections: %w", err))
+		if err := s.srv.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close connections: %w", err))
+		}
+	}
+
+	if err := s.repo.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,591 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"github.com/odysseymorphey/quotes-service/internal/search"
+)
+
+type record struct {
+	id    int64
+	quote models.Quote
+	// views and likes weight models.WeightPopular picks.
+	views int64
+	likes int64
+}
+
+type author struct {
+	id   int64
+	name string
+}
+
+// Storage keeps quotes in process memory. Records and authors are kept
+// ordered by id, which only grows, so lookups can use binary search.
+type Storage struct {
+	mu      sync.RWMutex
+	records []record
+	lastID  int64
+	authors []author
+	// authorIDs maps models.AuthorKey of a name to the author's id.
+	authorIDs map[string]int64
+}
+
+func New() *Storage {
+	return &Storage{authorIDs: make(map[string]int64)}
+}
+
+func (s *Storage) AddQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	q.Id = strconv.FormatInt(s.lastID, 10)
+	q.AuthorId = s.authorID(q.Author)
+	q.Tags = slices.Clone(q.Tags)
+	s.records = append(s.records, record{id: s.lastID, quote: q})
+
+	return &q, nil
+}
+
+func (s *Storage) AddQuotes(ctx context.Context, quotes []models.Quote) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, q := range quotes {
+		s.lastID++
+		q.Id = strconv.FormatInt(s.lastID, 10)
+		q.AuthorId = s.authorID(q.Author)
+		q.Tags = slices.Clone(q.Tags)
+		s.records = append(s.records, record{id: s.lastID, quote: q})
+	}
+
+	return len(quotes), nil
+}
+
+func (s *Storage) GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (*models.Page, error) {
+	return s.page(p, matcher(f)), nil
+}
+
+// AllQuotes iterates over a snapshot taken when iteration starts, so slow
+// consumers don't hold the lock.
+func (s *Storage) AllQuotes(ctx context.Context) iter.Seq2[models.Quote, error] {
+	return func(yield func(models.Quote, error) bool) {
+		s.mu.RLock()
+		records := slices.Clone(s.records)
+		s.mu.RUnlock()
+
+		for _, r := range records {
+			if err := ctx.Err(); err != nil {
+				yield(models.Quote{}, err)
+				return
+			}
+
+			if !yield(r.quote, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Storage) GetQuoteByID(ctx context.Context, id string) (*models.Quote, error) {
+	const op = "memory.GetQuoteByID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.find(id)
+	if !ok {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	quote := s.records[i].quote
+
+	return &quote, nil
+}
+
+func (s *Storage) SearchQuotes(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	terms := search.Terms(query)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []models.SearchResult{}
+	for _, r := range s.records {
+		if rank := search.Rank(r.quote.Quote, terms); rank > 0 {
+			results = append(results, models.SearchResult{Quote: r.quote, Rank: rank})
+		}
+	}
+
+	// The sort is stable and records are in id order, so ties keep id order.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	for i := range results {
+		results[i].Snippet = search.Highlight(results[i].Quote.Quote, terms)
+	}
+
+	return results, nil
+}
+
+// GetRandomQuote picks among the matching quotes with reservoir sampling, so
+// they don't have to be collected first.
+func (s *Storage) GetRandomQuote(ctx context.Context, f models.QuoteFilter, r models.RandomRequest) ([]models.Quote, error) {
+	const op = "memory.GetRandomQuote"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := matcher(f)
+
+	if r.Seed != nil {
+		return s.seededQuotes(op, match, r.N, *r.Seed)
+	}
+
+	if r.Weight == models.WeightPopular || r.Weight == models.WeightRecent {
+		return s.weightedQuotes(op, match, r.N, r.Weight)
+	}
+
+	n := r.N
+
+	var quotes []models.Quote
+	seen := 0
+	for _, r := range s.records {
+		if !match(r.quote) {
+			continue
+		}
+
+		seen++
+		if len(quotes) < n {
+			quotes = append(quotes, r.quote)
+		} else if i := rand.IntN(seen); i < n {
+			quotes[i] = r.quote
+		}
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	// The reservoir keeps the first quotes in id order until they are
+	// replaced, so it is shuffled to make the order random too.
+	rand.Shuffle(len(quotes), func(i, j int) {
+		quotes[i], quotes[j] = quotes[j], quotes[i]
+	})
+
+	return quotes, nil
+}
+
+// weightedQuotes must be called with s.mu held.
+func (s *Storage) weightedQuotes(op string, match func(models.Quote) bool, n int, w models.Weight) ([]models.Quote, error) {
+	type pick struct {
+		key   float64
+		quote models.Quote
+	}
+
+	var picks []pick
+	for _, r := range s.records {
+		if !match(r.quote) {
+			continue
+		}
+
+		weight := float64(r.id)
+		if w == models.WeightPopular {
+			weight = models.PopularWeight(r.views, r.likes)
+		}
+
+		picks = append(picks, pick{key: -math.Log(1-rand.Float64()) / weight, quote: r.quote})
+	}
+
+	if len(picks) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	slices.SortFunc(picks, func(a, b pick) int {
+		return cmp.Compare(a.key, b.key)
+	})
+
+	quotes := make([]models.Quote, 0, min(n, len(picks)))
+	for _, p := range picks[:min(n, len(picks))] {
+		quotes = append(quotes, p.quote)
+	}
+
+	return quotes, nil
+}
+
+// seededQuotes must be called with s.mu held.
+func (s *Storage) seededQuotes(op string, match func(models.Quote) bool, n int, seed uint64) ([]models.Quote, error) {
+	var matching []models.Quote
+	for _, r := range s.records {
+		if match(r.quote) {
+			matching = append(matching, r.quote)
+		}
+	}
+
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	quotes := make([]models.Quote, 0, n)
+	for _, i := range models.SeededIndexes(seed, n, len(matching)) {
+		quotes = append(quotes, matching[i])
+	}
+
+	return quotes, nil
+}
+
+func (s *Storage) CountQuotes(ctx context.Context, f models.QuoteFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := matcher(f)
+
+	count := 0
+	for _, r := range s.records {
+		if match(r.quote) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *Storage) GetQuoteAt(ctx context.Context, f models.QuoteFilter, i int) (*models.Quote, error) {
+	const op = "memory.GetQuoteAt"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := matcher(f)
+
+	for _, r := range s.records {
+		if !match(r.quote) {
+			continue
+		}
+
+		if i == 0 {
+			quote := r.quote
+			return &quote, nil
+		}
+		i--
+	}
+
+	return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+}
+
+func (s *Storage) UpdateQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
+	const op = "memory.UpdateQuote"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.find(q.Id)
+	if !ok {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	quote := &s.records[i].quote
+	quote.Author = q.Author
+	quote.Quote = q.Quote
+	quote.AuthorId = s.authorID(q.Author)
+	quote.Language = q.Language
+	quote.Tags = slices.Clone(q.Tags)
+	updated := *quote
+
+	return &updated, nil
+}
+
+func (s *Storage) PatchQuote(ctx context.Context, id string, p models.QuotePatch) (*models.Quote, error) {
+	const op = "memory.PatchQuote"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.find(id)
+	if !ok {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	quote := &s.records[i].quote
+	if p.Author != nil {
+		quote.Author = *p.Author
+		quote.AuthorId = s.authorID(*p.Author)
+	}
+	if p.Quote != nil {
+		quote.Quote = *p.Quote
+	}
+	if p.Language != nil {
+		quote.Language = *p.Language
+	}
+	if p.Tags != nil {
+		quote.Tags = slices.Clone(*p.Tags)
+	}
+	updated := *quote
+
+	return &updated, nil
+}
+
+func (s *Storage) ViewQuote(ctx context.Context, id string) error {
+	const op = "memory.ViewQuote"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.find(id)
+	if !ok {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	s.records[i].views++
+
+	return nil
+}
+
+func (s *Storage) LikeQuote(ctx context.Context, id string) error {
+	const op = "memory.LikeQuote"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.find(id)
+	if !ok {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	s.records[i].likes++
+
+	return nil
+}
+
+func (s *Storage) DeleteQuote(ctx context.Context, id string) error {
+	const op = "memory.DeleteQuote"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.find(id)
+	if !ok {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	s.records = append(s.records[:i], s.records[i+1:]...)
+
+	return nil
+}
+
+func (s *Storage) GetAuthors(ctx context.Context, p models.PageRequest) (*models.AuthorPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := s.quoteCounts()
+
+	start := sort.Search(len(s.authors), func(i int) bool {
+		return s.authors[i].id > p.After
+	})
+
+	var authors []models.Author
+	for _, a := range s.authors[start:] {
+		if len(authors) > p.Limit {
+			break
+		}
+		if n := counts[a.id]; n > 0 {
+			authors = append(authors, models.Author{Id: strconv.FormatInt(a.id, 10), Name: a.name, QuoteCount: n})
+		}
+	}
+
+	return models.NewAuthorPage(authors, p.Limit), nil
+}
+
+func (s *Storage) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	const op = "memory.GetAuthorByID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+
+	i, ok := slices.BinarySearchFunc(s.authors, n, func(a author, id int64) int {
+		return cmp.Compare(a.id, id)
+	})
+	if !ok {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+
+	count := s.quoteCounts()[n]
+	if count == 0 {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+
+	return &models.Author{Id: id, Name: s.authors[i].name, QuoteCount: count}, nil
+}
+
+func (s *Storage) GetTags(ctx context.Context) ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, r := range s.records {
+		for _, tag := range r.quote.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(counts))
+	for name, n := range counts {
+		tags = append(tags, models.Tag{Name: name, QuoteCount: n})
+	}
+
+	slices.SortFunc(tags, func(a, b models.Tag) int {
+		return cmp.Or(cmp.Compare(b.QuoteCount, a.QuoteCount), cmp.Compare(a.Name, b.Name))
+	})
+
+	return tags, nil
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// find returns the index of the record with the given id. Callers must hold
+// the lock.
+func (s *Storage) find(id string) (int, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	i := sort.Search(len(s.records), func(i int) bool {
+		return s.records[i].id >= n
+	})
+	if i == len(s.records) || s.records[i].id != n {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// authorID returns the id of the author with name's key, adding the author
+// if there is none yet. Callers must hold the write lock.
+func (s *Storage) authorID(name string) string {
+	key := models.AuthorKey(name)
+
+	id, ok := s.authorIDs[key]
+	if !ok {
+		id = int64(len(s.authors)) + 1
+		s.authors = append(s.authors, author{id: id, name: name})
+		s.authorIDs[key] = id
+	}
+
+	return strconv.FormatInt(id, 10)
+}
+
+// quoteCounts returns the number of quotes by author id. Callers must hold
+// the lock.
+func (s *Storage) quoteCounts() map[int64]int {
+	counts := make(map[int64]int)
+	for _, r := range s.records {
+		id, _ := strconv.ParseInt(r.quote.AuthorId, 10, 64)
+		counts[id]++
+	}
+
+	return counts
+}
+
+// matcher returns a predicate that reports whether a quote matches f.
+func matcher(f models.QuoteFilter) func(models.Quote) bool {
+	var preds []func(models.Quote) bool
+
+	if f.Author != "" {
+		preds = append(preds, authorMatcher(f.Author, f.AuthorMatch))
+	}
+	if f.AuthorId != "" {
+		preds = append(preds, func(q models.Quote) bool { return q.AuthorId == f.AuthorId })
+	}
+	if f.Language != "" {
+		preds = append(preds, func(q models.Quote) bool { return q.Language == f.Language })
+	}
+	if f.MaxLength > 0 {
+		preds = append(preds, func(q models.Quote) bool { return utf8.RuneCountInString(q.Quote) <= f.MaxLength })
+	}
+	if len(f.Tags) > 0 {
+		preds = append(preds, func(q models.Quote) bool { return hasTags(q, f.Tags) })
+	}
+
+	return func(q models.Quote) bool {
+		for _, pred := range preds {
+			if !pred(q) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+func authorMatcher(name string, match models.AuthorMatch) func(models.Quote) bool {
+	author := strings.ToLower(name)
+
+	switch match {
+	case models.AuthorIExact:
+		return func(q models.Quote) bool { return strings.ToLower(q.Author) == author }
+	case models.AuthorPrefix:
+		return func(q models.Quote) bool { return strings.HasPrefix(strings.ToLower(q.Author), author) }
+	case models.AuthorContains:
+		return func(q models.Quote) bool { return strings.Contains(strings.ToLower(q.Author), author) }
+	}
+
+	return func(q models.Quote) bool { return q.Author == name }
+}
+
+// hasTags reports whether q has every one of tags.
+func hasTags(q models.Quote, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(q.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// page collects up to p.Limit+1 quotes after p.After that satisfy match.
+func (s *Storage) page(p models.PageRequest, match func(models.Quote) bool) *models.Page {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := sort.Search(len(s.records), func(i int) bool {
+		return s.records[i].id > p.After
+	})
+
+	var quotes []models.Quote
+	for _, r := range s.records[start:] {
+		if len(quotes) > p.Limit {
+			break
+		}
+		if match(r.quote) {
+			quotes = append(quotes, r.quote)
+		}
+	}
+
+	return models.NewPage(quotes, p.Limit)
+}
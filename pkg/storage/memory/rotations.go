@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+)
+
+// Rotations keeps client rotations in process memory. Only the max most
+// recently used clients are kept, so made up client ids can't grow it without
+// bound; a client that was dropped starts a new rotation.
+type Rotations struct {
+	mu  sync.Mutex
+	max int
+	// clients maps client ids to elements of order, the most recently used
+	// first.
+	clients map[string]*list.Element
+	order   *list.List
+}
+
+type clientRotation struct {
+	client   string
+	rotation models.Rotation
+}
+
+func NewRotations(max int) *Rotations {
+	return &Rotations{
+		max:     max,
+		clients: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (r *Rotations) NextRotation(ctx context.Context, client string, n int) (models.Rotation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.clients[client]
+	if ok {
+		r.order.MoveToFront(e)
+	} else {
+		if r.order.Len() >= r.max {
+			oldest := r.order.Back()
+			r.order.Remove(oldest)
+			delete(r.clients, oldest.Value.(*clientRotation).client)
+		}
+
+		e = r.order.PushFront(&clientRotation{client: client, rotation: models.Rotation{Seed: rand.Uint64()}})
+		r.clients[client] = e
+	}
+
+	c := e.Value.(*clientRotation)
+	rotation := c.rotation
+	c.rotation.Position += n
+
+	return rotation, nil
+}
@@ -3,15 +3,108 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"iter"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// schema creates the tables of an empty database and brings ones created by
+// earlier versions up to date, so every statement in it must be a no-op when
+// its part is already there. Columns added since the first version go in the
+// ALTER TABLE rather than in CREATE TABLE quotes.
+const schema = `
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- name_key is models.AuthorKey of the name.
+CREATE TABLE IF NOT EXISTS authors (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL,
+    name_key TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS quotes (
+    id SERIAL PRIMARY KEY,
+    author VARCHAR(30) NOT NULL,
+    quote TEXT NOT NULL
+);
+
+-- author_id is made NOT NULL by linkAuthors. language is an ISO 639 code,
+-- empty when unknown.
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS author_id INTEGER,
+    ADD COLUMN IF NOT EXISTS language VARCHAR(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (to_tsvector('simple', quote)) STORED;
+
+CREATE INDEX IF NOT EXISTS quotes_author_id_idx ON quotes (author_id);
+
+CREATE INDEX IF NOT EXISTS quotes_language_idx ON quotes (language);
+
+CREATE INDEX IF NOT EXISTS quotes_search_idx ON quotes USING GIN (search);
+
+CREATE INDEX IF NOT EXISTS quotes_author_trgm_idx ON quotes USING GIN (author gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS quote_tags (
+    quote_id INTEGER NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (quote_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS quote_tags_tag_id_idx ON quote_tags (tag_id, quote_id);
+
+-- Counters that weight popular random picks. They are kept out of quotes so
+-- that counting doesn't rewrite quote rows and their search column.
+CREATE TABLE IF NOT EXISTS quote_counters (
+    quote_id INTEGER PRIMARY KEY REFERENCES quotes(id) ON DELETE CASCADE,
+    views BIGINT NOT NULL DEFAULT 0,
+    likes BIGINT NOT NULL DEFAULT 0
+);
+
+-- Where each client is in its rotation through the quotes, see
+-- models.Rotation. position is the next position; updated_at tells which
+-- rotations are stale, see pruneRotations.
+CREATE TABLE IF NOT EXISTS client_rotations (
+    client_id TEXT PRIMARY KEY,
+    seed BIGINT NOT NULL,
+    position BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS client_rotations_updated_at_idx ON client_rotations (updated_at);
+`
+
+// migrateLock is the advisory lock that keeps instances starting together
+// from migrating the same database at once.
+const migrateLock = 0x71756f746573
+
 type Database struct {
 	Db *sql.DB
+	// Log gets the outcome of every operation, named by its op. It is
+	// slog.Default when nil.
+	Log *slog.Logger
+	// RotationTTL is how long a client rotation is kept after its last use.
+	// Zero keeps rotations forever.
+	RotationTTL time.Duration
+
+	// rotations counts NextRotation calls, to prune every pruneEvery.
+	rotations atomic.Uint64
 }
 
+// New connects to the database at dsn, creating the schema or migrating it
+// to the current one.
 func New(dsn string) (*Database, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -22,93 +115,660 @@ func New(dsn string) (*Database, error) {
 		return nil, fmt.Errorf("can't ping database")
 	}
 
+	if err = migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't migrate schema: %v", err)
+	}
+
 	return &Database{
 		Db: db,
 	}, nil
 }
 
-func (d *Database) AddQuote(ctx context.Context, q models.Quote) error {
+func (d *Database) AddQuote(ctx context.Context, q models.Quote) (_ *models.Quote, err error) {
 	const op = "postgres.AddQuote"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	query := `
+		WITH a AS (` + upsertAuthor + `),
+		t AS (` + upsertTags + `),
+		q AS (
+			INSERT INTO quotes(author, quote, author_id, language) SELECT $1, $2, id, $5 FROM a
+			RETURNING id, author, quote, author_id, language
+		),
+		qt AS (INSERT INTO quote_tags(quote_id, tag_id) SELECT q.id, t.id FROM q, t)
+		SELECT id, author, quote, author_id, language, $4::text[] FROM q`
 
-	query := `INSERT INTO quotes(author, quote) VALUES ($1, $2)`
+	row := d.Db.QueryRowContext(ctx, query, q.Author, q.Quote, models.AuthorKey(q.Author), pq.Array(q.Tags), q.Language)
 
-	_, err := d.Db.ExecContext(ctx, query, q.Author, q.Quote)
+	quote, err := scanQuote(row)
 	if err != nil {
-		return fmt.Errorf("%s: failed to execute query: %v", op, err)
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
 	}
 
-	return nil
+	return &quote, nil
+}
+
+// AddQuotes loads quotes with COPY, which is much faster than one INSERT per
+// row for large imports. COPY can't return the new ids, so they are taken from
+// the sequence beforehand to link the tags.
+func (d *Database) AddQuotes(ctx context.Context, quotes []models.Quote) (_ int, err error) {
+	const op = "postgres.AddQuotes"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	tx, err := d.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	authorIDs, err := addAuthors(ctx, tx, quotes)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to add authors: %w", op, classify(err))
+	}
+
+	ids, err := reserveQuoteIDs(ctx, tx, len(quotes))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to reserve ids: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("quotes", "id", "author", "quote", "author_id", "language"))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare copy: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for i, q := range quotes {
+		if _, err = stmt.ExecContext(ctx, ids[i], q.Author, q.Quote, authorIDs[models.AuthorKey(q.Author)], q.Language); err != nil {
+			return 0, fmt.Errorf("%s: failed to copy row: %w", op, classify(err))
+		}
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("%s: failed to flush copy: %w", op, classify(err))
+	}
+
+	if err = stmt.Close(); err != nil {
+		return 0, fmt.Errorf("%s: failed to finish copy: %w", op, classify(err))
+	}
+
+	if err = addQuoteTags(ctx, tx, ids, quotes); err != nil {
+		return 0, fmt.Errorf("%s: failed to add tags: %w", op, classify(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return len(quotes), nil
+}
+
+// addAuthors creates the missing authors of quotes and returns the ids of all
+// of them by key. A key must appear only once in a single upsert, so variants
+// within the batch are folded first and the first spelling wins.
+func addAuthors(ctx context.Context, tx *sql.Tx, quotes []models.Quote) (map[string]int64, error) {
+	var names, keys []string
+	seen := make(map[string]bool)
+
+	for _, q := range quotes {
+		key := models.AuthorKey(q.Author)
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, q.Author)
+			keys = append(keys, key)
+		}
+	}
+
+	query := `
+		INSERT INTO authors(name, name_key) SELECT * FROM unnest($1::varchar[], $2::text[])
+		ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+		RETURNING id, name_key`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(names), pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64, len(keys))
+	for rows.Next() {
+		var id int64
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, err
+		}
+
+		ids[key] = id
+	}
+
+	return ids, rows.Err()
+}
+
+func reserveQuoteIDs(ctx context.Context, tx *sql.Tx, n int) ([]int64, error) {
+	query := `SELECT nextval(pg_get_serial_sequence('quotes', 'id')) FROM generate_series(1, $1)`
+
+	rows, err := tx.QueryContext(ctx, query, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// addQuoteTags creates the missing tags of quotes and links them to the
+// quotes with the matching ids. It does nothing if no quote has tags.
+func addQuoteTags(ctx context.Context, tx *sql.Tx, ids []int64, quotes []models.Quote) error {
+	var quoteIDs []int64
+	var names []string
+
+	for i, q := range quotes {
+		for _, tag := range q.Tags {
+			quoteIDs = append(quoteIDs, ids[i])
+			names = append(names, tag)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	query := `
+		WITH t AS (
+			INSERT INTO tags(name) SELECT DISTINCT unnest($2::text[])
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, name
+		)
+		INSERT INTO quote_tags(quote_id, tag_id)
+		SELECT qt.quote_id, t.id FROM unnest($1::int[], $2::text[]) AS qt(quote_id, name) JOIN t ON t.name = qt.name`
+
+	_, err := tx.ExecContext(ctx, query, pq.Array(quoteIDs), pq.Array(names))
+
+	return err
 }
 
-func (d *Database) GetQuotes(ctx context.Context) ([]models.Quote, error) {
+func (d *Database) GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (_ *models.Page, err error) {
 	const op = "postgres.GetQuotes"
+	defer d.observe(ctx, op, time.Now(), &err)
 
-	query := `SELECT * FROM quotes`
+	conds, args := filterConditions(f)
+	args = append(args, p.After, p.Limit+1)
+	conds = append(conds, fmt.Sprintf("id > $%d", len(args)-1))
 
-	rows, err := d.Db.QueryContext(ctx, query)
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE ` + strings.Join(conds, " AND ") +
+		fmt.Sprintf(` ORDER BY id LIMIT $%d`, len(args))
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to execute query: %v", op, err)
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
 	}
 
-	var quotes []models.Quote
+	quotes, err := scanQuotes(op, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewPage(quotes, p.Limit), nil
+}
+
+func (d *Database) AllQuotes(ctx context.Context) iter.Seq2[models.Quote, error] {
+	const op = "postgres.AllQuotes"
+
+	return func(yield func(models.Quote, error) bool) {
+		var err error
+		defer d.observe(ctx, op, time.Now(), &err)
+
+		query := `SELECT ` + quoteColumns + ` FROM quotes ORDER BY id`
+
+		rows, err := d.Db.QueryContext(ctx, query)
+		if err != nil {
+			err = fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+			yield(models.Quote{}, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var quote models.Quote
+			if quote, err = scanQuote(rows); err != nil {
+				err = fmt.Errorf("%s: failed to scan row: %w", op, err)
+				yield(models.Quote{}, err)
+				return
+			}
+
+			if !yield(quote, nil) {
+				return
+			}
+		}
+
+		if err = rows.Err(); err != nil {
+			err = fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+			yield(models.Quote{}, err)
+		}
+	}
+}
+
+func (d *Database) GetQuoteByID(ctx context.Context, id string) (_ *models.Quote, err error) {
+	const op = "postgres.GetQuoteByID"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE id = $1`
+
+	row := d.Db.QueryRowContext(ctx, query, id)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &quote, nil
+}
+
+// SearchQuotes uses the GIN-indexed search column. The text is HTML-escaped
+// before highlighting; the parser treats the escapes as entities rather than
+// words, so they can't match.
+func (d *Database) SearchQuotes(ctx context.Context, query string, limit int) (_ []models.SearchResult, err error) {
+	const op = "postgres.SearchQuotes"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	q := `
+		SELECT ` + quoteColumns + `, ts_rank(search, query) AS rank,
+			ts_headline('simple', replace(replace(replace(quote, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query,
+				'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet
+		FROM quotes, websearch_to_tsquery('simple', $1) AS query
+		WHERE search @@ query
+		ORDER BY rank DESC, id
+		LIMIT $2`
+
+	rows, err := d.Db.QueryContext(ctx, q, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
 	for rows.Next() {
-		var quote models.Quote
-		if err := rows.Scan(&quote.Id, &quote.Author, &quote.Quote); err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %v", op, err)
+		var r models.SearchResult
+		var err error
+		if r.Quote, err = scanQuote(rows, &r.Rank, &r.Snippet); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
 		}
 
-		quotes = append(quotes, quote)
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// GetRandomQuote picks quotes that match no filter by sampling random ids in
+// the range of quote ids and keeping the ones that hit a quote, so a pick
+// costs a few primary key lookups instead of a sort of the whole table. Ids
+// that fall in a gap are sampled again, which keeps every quote equally
+// likely. Filtered picks, and tables with too many gaps for the samples to
+// hit n quotes in randomRounds rounds, take random indexes among the matching
+// quotes instead, which reads their ids but doesn't sort them.
+func (d *Database) GetRandomQuote(ctx context.Context, f models.QuoteFilter, r models.RandomRequest) (_ []models.Quote, err error) {
+	const op = "postgres.GetRandomQuote"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if r.Seed != nil {
+		return d.seededQuotes(ctx, f, r.N, *r.Seed)
+	}
+
+	if r.Weight == models.WeightPopular || r.Weight == models.WeightRecent {
+		return d.weightedQuotes(ctx, f, r.N, r.Weight)
+	}
+
+	if conds, _ := filterConditions(f); len(conds) > 0 {
+		return d.seededQuotes(ctx, f, r.N, rand.Uint64())
+	}
+
+	var quotes []models.Quote
+	seen := make(map[string]bool, r.N)
+
+	for range randomRounds {
+		sampled, err := d.sampleQuotes(ctx, randomSamples*(r.N-len(quotes)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, quote := range sampled {
+			if !seen[quote.Id] && len(quotes) < r.N {
+				seen[quote.Id] = true
+				quotes = append(quotes, quote)
+			}
+		}
+
+		if len(quotes) == r.N {
+			return quotes, nil
+		}
+	}
+
+	// There are fewer than n quotes, or too few of the ids hit one.
+	return d.seededQuotes(ctx, f, r.N, rand.Uint64())
+}
+
+// sampleQuotes looks up samples random ids between the lowest and the highest
+// quote id and returns the quotes they hit, in random order.
+func (d *Database) sampleQuotes(ctx context.Context, samples int) ([]models.Quote, error) {
+	const op = "postgres.GetRandomQuote"
+
+	query := `
+		WITH b AS (SELECT min(id) AS lo, max(id) AS hi FROM quotes),
+		p AS (
+			SELECT DISTINCT (lo + floor(random() * (hi - lo + 1)))::int AS id
+			FROM b, generate_series(1, $1)
+		)
+		SELECT q.* FROM p CROSS JOIN LATERAL (SELECT ` + quoteColumns + ` FROM quotes WHERE id = p.id) q
+		ORDER BY random()`
+
+	rows, err := d.Db.QueryContext(ctx, query, samples)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	return scanQuotes(op, rows)
+}
+
+func (d *Database) weightedQuotes(ctx context.Context, f models.QuoteFilter, n int, w models.Weight) ([]models.Quote, error) {
+	const op = "postgres.GetRandomQuote"
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes`
+
+	weight := `quotes.id`
+	if w == models.WeightPopular {
+		weight = fmt.Sprintf(`(1 + COALESCE(c.views, 0) + %d * COALESCE(c.likes, 0))`, models.LikeViews)
+		query += ` LEFT JOIN quote_counters c ON c.quote_id = quotes.id`
+	}
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	args = append(args, n)
+	query += fmt.Sprintf(` ORDER BY -ln(1 - random()) / %s LIMIT $%d`, weight, len(args))
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	quotes, err := scanQuotes(op, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
 	}
 
 	return quotes, nil
 }
 
-func (d *Database) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
-	const op = "postgres.GetQuotesByAuthor"
+func (d *Database) seededQuotes(ctx context.Context, f models.QuoteFilter, n int, seed uint64) ([]models.Quote, error) {
+	const op = "postgres.GetRandomQuote"
 
-	query := `SELECT * FROM quotes WHERE author = $1`
+	count, err := d.CountQuotes(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if count == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
 
-	rows, err := d.Db.QueryContext(ctx, query, author)
+	indexes := models.SeededIndexes(seed, n, count)
+
+	var where string
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		where = ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	args = append(args, pq.Array(indexes))
+
+	query := fmt.Sprintf(`
+		SELECT %s, m.i FROM (
+			SELECT id AS quote_id, row_number() OVER (ORDER BY id) - 1 AS i FROM quotes%s
+		) m JOIN quotes ON quotes.id = m.quote_id
+		WHERE m.i = ANY($%d)`, quoteColumns, where, len(args))
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to execute query: %v", op, err)
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
 	}
+	defer rows.Close()
 
-	var quotes []models.Quote
+	byIndex := make(map[int]models.Quote, len(indexes))
 	for rows.Next() {
-		var quote models.Quote
-		if err := rows.Scan(&quote.Id, &quote.Author, &quote.Quote); err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %v", op, err)
+		var i int
+		quote, err := scanQuote(rows, &i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
 		}
 
-		quotes = append(quotes, quote)
+		byIndex[i] = quote
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	quotes := models.PickIndexed(byIndex, indexes)
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
 	}
 
 	return quotes, nil
 }
 
-func (d *Database) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
-	const op = "postgres.GetRandomQuote"
+// randomSamples is how many ids GetRandomQuote samples per quote it still
+// needs, so that a few ids falling in gaps or on the same quote don't leave
+// it short, and randomRounds is how many times it samples before giving up.
+const (
+	randomSamples = 4
+	randomRounds  = 3
+)
 
-	query := `SELECT * FROM quotes ORDER BY random() LIMIT 1`
+func (d *Database) CountQuotes(ctx context.Context, f models.QuoteFilter) (_ int, err error) {
+	const op = "postgres.CountQuotes"
+	defer d.observe(ctx, op, time.Now(), &err)
 
-	row := d.Db.QueryRowContext(ctx, query)
+	query := `SELECT count(*) FROM quotes`
 
-	var quote models.Quote
-	err := row.Scan(&quote.Id, &quote.Author, &quote.Quote)
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+
+	var count int
+	if err := d.Db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return count, nil
+}
+
+func (d *Database) GetQuoteAt(ctx context.Context, f models.QuoteFilter, i int) (_ *models.Quote, err error) {
+	const op = "postgres.GetQuoteAt"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes`
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	args = append(args, i)
+	query += fmt.Sprintf(` ORDER BY id OFFSET $%d LIMIT 1`, len(args))
+
+	row := d.Db.QueryRowContext(ctx, query, args...)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("%s: failed to scan row: %v", op, err)
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
 	}
 
 	return &quote, nil
 }
-func (d *Database) DeleteQuote(ctx context.Context, id string) error {
+
+func (d *Database) UpdateQuote(ctx context.Context, q models.Quote) (_ *models.Quote, err error) {
+	const op = "postgres.UpdateQuote"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(q.Id, 10, 32); err != nil {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	query := `
+		WITH a AS (` + upsertAuthor + `),
+		t AS (` + upsertTags + `),
+		q AS (
+			UPDATE quotes SET author = $1, quote = $2, author_id = a.id, language = $6 FROM a WHERE quotes.id = $5
+			RETURNING quotes.id, author, quote, author_id, language
+		),` + replaceTags + `
+		SELECT id, author, quote, author_id, language, $4::text[] FROM q`
+
+	// A nil array would be sent as NULL and leave the old tags in place.
+	tags := append([]string{}, q.Tags...)
+
+	row := d.Db.QueryRowContext(ctx, query, q.Author, q.Quote, models.AuthorKey(q.Author), pq.Array(tags), q.Id, q.Language)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) PatchQuote(ctx context.Context, id string, p models.QuotePatch) (_ *models.Quote, err error) {
+	const op = "postgres.PatchQuote"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	var key *string
+	if p.Author != nil {
+		k := models.AuthorKey(*p.Author)
+		key = &k
+	}
+
+	// A nil array is sent as NULL, which leaves the tags unchanged, so
+	// removing all of them needs an empty one.
+	var tags []string
+	if p.Tags != nil {
+		tags = append([]string{}, *p.Tags...)
+	}
+
+	query := `
+		WITH a AS (
+			INSERT INTO authors(name, name_key) SELECT $1::varchar, $3::text WHERE $1 IS NOT NULL
+			ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+			RETURNING id
+		),
+		t AS (` + upsertTags + `),
+		q AS (
+			UPDATE quotes SET author = COALESCE($1, author), quote = COALESCE($2, quote),
+				author_id = COALESCE((SELECT id FROM a), author_id), language = COALESCE($6, language)
+			WHERE id = $5
+			RETURNING id, author, quote, author_id, language
+		),` + replaceTags + `
+		SELECT id, author, quote, author_id, language, COALESCE($4::text[], ARRAY(
+			SELECT t.name FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = q.id ORDER BY t.name
+		))
+		FROM q`
+
+	row := d.Db.QueryRowContext(ctx, query, p.Author, p.Quote, key, pq.Array(tags), id, p.Language)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) ViewQuote(ctx context.Context, id string) error {
+	return d.countQuote(ctx, "postgres.ViewQuote", id, "views")
+}
+
+func (d *Database) LikeQuote(ctx context.Context, id string) error {
+	return d.countQuote(ctx, "postgres.LikeQuote", id, "likes")
+}
+
+func (d *Database) countQuote(ctx context.Context, op, id, column string) (err error) {
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO quote_counters(quote_id, %[1]s) SELECT id, 1 FROM quotes WHERE id = $1
+		ON CONFLICT (quote_id) DO UPDATE SET %[1]s = quote_counters.%[1]s + 1`, column)
+
+	res, err := d.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteQuote(ctx context.Context, id string) (err error) {
 	const op = "postgres.DeleteQuote"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
 
 	query := `DELETE FROM quotes WHERE id = $1`
 
 	res, err := d.Db.ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("%s: failed to execute query: %w", op, err)
+		return fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
 	}
 
 	rowsAffected, err := res.RowsAffected()
@@ -117,16 +777,437 @@ func (d *Database) DeleteQuote(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s: quote not found", op)
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
 	}
 
 	return nil
 }
 
+func (d *Database) GetAuthors(ctx context.Context, p models.PageRequest) (_ *models.AuthorPage, err error) {
+	const op = "postgres.GetAuthors"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	query := `
+		SELECT a.id, a.name, count(*) FROM authors a JOIN quotes q ON q.author_id = a.id
+		WHERE a.id > $1 GROUP BY a.id ORDER BY a.id LIMIT $2`
+
+	rows, err := d.Db.QueryContext(ctx, query, p.After, p.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	var authors []models.Author
+	for rows.Next() {
+		var author models.Author
+		if err := rows.Scan(&author.Id, &author.Name, &author.QuoteCount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		authors = append(authors, author)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return models.NewAuthorPage(authors, p.Limit), nil
+}
+
+func (d *Database) GetAuthorByID(ctx context.Context, id string) (_ *models.Author, err error) {
+	const op = "postgres.GetAuthorByID"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+
+	query := `
+		SELECT a.id, a.name, count(*) FROM authors a JOIN quotes q ON q.author_id = a.id
+		WHERE a.id = $1 GROUP BY a.id`
+
+	row := d.Db.QueryRowContext(ctx, query, id)
+
+	var author models.Author
+	err = row.Scan(&author.Id, &author.Name, &author.QuoteCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &author, nil
+}
+
+func (d *Database) GetTags(ctx context.Context) (_ []models.Tag, err error) {
+	const op = "postgres.GetTags"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	query := `
+		SELECT t.name, count(*) FROM tags t JOIN quote_tags qt ON qt.tag_id = t.id
+		GROUP BY t.id ORDER BY count(*) DESC, t.name`
+
+	rows, err := d.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.QuoteCount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// NextRotation keeps rotations in the client_rotations table, so that every
+// instance of the service shares them.
+func (d *Database) NextRotation(ctx context.Context, client string, n int) (_ models.Rotation, err error) {
+	const op = "postgres.NextRotation"
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	// The seed is only stored for a new client. position is the next
+	// position, so the one to return is n before it.
+	query := `
+		INSERT INTO client_rotations(client_id, seed, position) VALUES ($1, $2, $3)
+		ON CONFLICT (client_id) DO UPDATE SET position = client_rotations.position + $3, updated_at = now()
+		RETURNING seed, position - $3`
+
+	var seed int64
+	var rotation models.Rotation
+	if err := d.Db.QueryRowContext(ctx, query, client, int64(rand.Uint64()), n).Scan(&seed, &rotation.Position); err != nil {
+		return models.Rotation{}, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+	rotation.Seed = uint64(seed)
+
+	if d.RotationTTL > 0 && d.rotations.Add(1)%pruneEvery == 0 {
+		d.pruneRotations(ctx)
+	}
+
+	return rotation, nil
+}
+
+// Every pruneEvery rotations, up to pruneBatch stale ones are deleted. That
+// removes stale rotations faster than new clients can add them, and keeps
+// each pruning short.
+const (
+	pruneEvery = 100
+	pruneBatch = 1000
+)
+
+// pruneRotations deletes rotations unused for longer than d.RotationTTL, so
+// that made-up client ids can't grow the table without bound. A failure is
+// only logged, as the rotation it runs after is fine.
+func (d *Database) pruneRotations(ctx context.Context) {
+	const op = "postgres.pruneRotations"
+
+	var err error
+	defer d.observe(ctx, op, time.Now(), &err)
+
+	query := `
+		DELETE FROM client_rotations WHERE client_id IN (
+			SELECT client_id FROM client_rotations WHERE updated_at < now() - $1 * interval '1 second'
+			ORDER BY updated_at LIMIT $2
+		)`
+
+	if _, err = d.Db.ExecContext(ctx, query, d.RotationTTL.Seconds(), pruneBatch); err != nil {
+		err = fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+}
+
+func (d *Database) log() *slog.Logger {
+	if d.Log != nil {
+		return d.Log
+	}
+
+	return slog.Default()
+}
+
+// observe logs how op, which began at start, ended with err. Successes and
+// failures caused by the request, such as a missing quote, are only logged at
+// debug level.
+func (d *Database) observe(ctx context.Context, op string, start time.Time, err *error) {
+	level, msg := slog.LevelDebug, "Query done"
+	switch {
+	case *err == nil:
+	case errors.Is(*err, repository.ErrNotFound), errors.Is(*err, repository.ErrConflict), errors.Is(*err, repository.ErrInvalid):
+		msg = "Query failed"
+	case errors.Is(*err, repository.ErrCanceled), errors.Is(*err, context.Canceled), errors.Is(*err, context.DeadlineExceeded):
+		level, msg = slog.LevelWarn, "Query canceled"
+	default:
+		level, msg = slog.LevelError, "Query failed"
+	}
+
+	l := d.log()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("op", op), slog.Duration("latency", time.Since(start))}
+	if *err != nil {
+		attrs = append(attrs, slog.Any("error", *err))
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
+
 func (d *Database) Close() error {
 	if err := d.Db.Close(); err != nil {
-		return fmt.Errorf("failed to close database: %v", err)
+		return fmt.Errorf("failed to close database: %w", err)
 	}
 
 	return nil
 }
+
+// migrate applies schema and links the existing quotes to authors in one
+// transaction, so a failed migration leaves the database as it was.
+func migrate(db *sql.DB) error {
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrateLock); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+
+	if err = linkAuthors(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// linkAuthors adds authors for quotes stored before the authors table
+// existed, then makes author_id required. It does nothing once it is. The
+// keys come from models.AuthorKey, which has no SQL counterpart.
+func linkAuthors(ctx context.Context, tx *sql.Tx) error {
+	query := `
+		SELECT is_nullable = 'YES' FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'quotes' AND column_name = 'author_id'`
+
+	var nullable bool
+	if err := tx.QueryRowContext(ctx, query).Scan(&nullable); err != nil || !nullable {
+		return err
+	}
+
+	quotes, err := unlinkedAuthors(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if len(quotes) > 0 {
+		ids, err := addAuthors(ctx, tx, quotes)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(quotes))
+		authorIDs := make([]int64, 0, len(quotes))
+		for _, q := range quotes {
+			names = append(names, q.Author)
+			authorIDs = append(authorIDs, ids[models.AuthorKey(q.Author)])
+		}
+
+		query = `
+			UPDATE quotes SET author_id = m.id FROM unnest($1::varchar[], $2::int[]) AS m(author, id)
+			WHERE quotes.author = m.author AND quotes.author_id IS NULL`
+
+		if _, err = tx.ExecContext(ctx, query, pq.Array(names), pq.Array(authorIDs)); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `ALTER TABLE quotes ALTER COLUMN author_id SET NOT NULL, ADD FOREIGN KEY (author_id) REFERENCES authors(id)`)
+
+	return err
+}
+
+// unlinkedAuthors returns a quote for each author name without an author, the
+// oldest first, so that addAuthors keeps the first spelling of a name.
+func unlinkedAuthors(ctx context.Context, tx *sql.Tx) ([]models.Quote, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT author FROM quotes WHERE author_id IS NULL GROUP BY author ORDER BY min(id)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		var q models.Quote
+		if err := rows.Scan(&q.Author); err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, q)
+	}
+
+	return quotes, rows.Err()
+}
+
+func scanQuotes(op string, rows *sql.Rows) ([]models.Quote, error) {
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		quotes = append(quotes, quote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return quotes, nil
+}
+
+// scanQuote scans a row selected with quoteColumns followed by extra columns.
+func scanQuote(row interface{ Scan(...any) error }, extra ...any) (models.Quote, error) {
+	var quote models.Quote
+
+	dest := append([]any{&quote.Id, &quote.Author, &quote.Quote, &quote.AuthorId, &quote.Language, pq.Array(&quote.Tags)}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.Quote{}, err
+	}
+
+	if len(quote.Tags) == 0 {
+		quote.Tags = nil
+	}
+
+	return quote, nil
+}
+
+// quoteTags selects the sorted tag names of the current quotes row.
+const quoteTags = `ARRAY(
+	SELECT t.name FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id
+	WHERE qt.quote_id = quotes.id ORDER BY t.name
+)`
+
+const quoteColumns = `id, author, quote, author_id, language, ` + quoteTags
+
+// upsertTags creates the tags named in $4 that don't exist yet and yields the
+// ids of all of them, the same way as upsertAuthor.
+const upsertTags = `
+	INSERT INTO tags(name) SELECT unnest($4::text[])
+	ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+	RETURNING id`
+
+// replaceTags replaces the tags of the quote updated in q with the ones in t
+// unless $4 is NULL. All parts of a statement see the same snapshot, so links
+// that are kept are left alone rather than deleted and inserted again.
+const replaceTags = `
+	dt AS (
+		DELETE FROM quote_tags WHERE $4::text[] IS NOT NULL
+			AND quote_id IN (SELECT id FROM q) AND tag_id NOT IN (SELECT id FROM t)
+	),
+	it AS (
+		INSERT INTO quote_tags(quote_id, tag_id) SELECT q.id, t.id FROM q, t
+		ON CONFLICT DO NOTHING
+	)`
+
+// upsertAuthor creates the author named $1 with key $3 unless the key is
+// taken, and yields the author's id either way: unlike DO NOTHING, the no-op
+// DO UPDATE makes RETURNING include an existing row.
+const upsertAuthor = `
+	INSERT INTO authors(name, name_key) VALUES ($1, $3)
+	ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+	RETURNING id`
+
+// filterConditions returns the WHERE conditions for f with their arguments,
+// numbering placeholders from $1. Case-insensitive author matches use ILIKE,
+// which the trigram index on author can serve for any of the patterns.
+func filterConditions(f models.QuoteFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+
+	if f.Author != "" {
+		switch f.AuthorMatch {
+		case models.AuthorIExact:
+			args = append(args, escapeLike(f.Author))
+			conds = append(conds, fmt.Sprintf("author ILIKE $%d", len(args)))
+		case models.AuthorPrefix:
+			args = append(args, escapeLike(f.Author)+"%")
+			conds = append(conds, fmt.Sprintf("author ILIKE $%d", len(args)))
+		case models.AuthorContains:
+			args = append(args, "%"+escapeLike(f.Author)+"%")
+			conds = append(conds, fmt.Sprintf("author ILIKE $%d", len(args)))
+		default:
+			args = append(args, f.Author)
+			conds = append(conds, fmt.Sprintf("author = $%d", len(args)))
+		}
+	}
+
+	if f.AuthorId != "" {
+		args = append(args, f.AuthorId)
+		conds = append(conds, fmt.Sprintf("author_id = $%d", len(args)))
+	}
+
+	if f.Language != "" {
+		args = append(args, f.Language)
+		conds = append(conds, fmt.Sprintf("language = $%d", len(args)))
+	}
+
+	if f.MaxLength > 0 {
+		args = append(args, f.MaxLength)
+		conds = append(conds, fmt.Sprintf("char_length(quote) <= $%d", len(args)))
+	}
+
+	if len(f.Tags) > 0 {
+		args = append(args, pq.Array(f.Tags), len(f.Tags))
+		conds = append(conds, fmt.Sprintf(`id IN (
+			SELECT qt.quote_id FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id
+			WHERE t.name = ANY($%d) GROUP BY qt.quote_id HAVING count(*) = $%d
+		)`, len(args)-1, len(args)))
+	}
+
+	return conds, args
+}
+
+// escapeLike escapes the LIKE wildcards in s with the default backslash escape.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// classify wraps the pq errors that repository errors stand for, told apart
+// by the names of their SQLSTATE codes.
+func classify(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation", "exclusion_violation":
+		return fmt.Errorf("%w: %w", repository.ErrConflict, err)
+	case "string_data_right_truncation", "invalid_text_representation", "numeric_value_out_of_range",
+		"not_null_violation", "check_violation", "foreign_key_violation":
+		return fmt.Errorf("%w: %w", repository.ErrInvalid, err)
+	case "query_canceled":
+		return fmt.Errorf("%w: %w", repository.ErrCanceled, err)
+	}
+
+	return err
+}
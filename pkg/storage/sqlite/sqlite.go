@@ -0,0 +1,956 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"github.com/odysseymorphey/quotes-service/internal/search"
+	"iter"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strings"
+)
+
+// The quotes_fts index is kept in sync by triggers, see migrate for databases
+// created before it existed. name_key folds spelling variants of a name, see
+// models.AuthorKey.
+const schema = `
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL CHECK (length(name) <= 30),
+    name_key TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS quotes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    author TEXT NOT NULL CHECK (length(author) <= 30),
+    quote TEXT NOT NULL,
+    author_id INTEGER NOT NULL REFERENCES authors(id),
+    language TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE CHECK (length(name) <= 30)
+);
+
+CREATE TABLE IF NOT EXISTS quote_tags (
+    quote_id INTEGER NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (quote_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS quote_tags_tag_id_idx ON quote_tags (tag_id, quote_id);
+
+CREATE TABLE IF NOT EXISTS quote_counters (
+    quote_id INTEGER PRIMARY KEY REFERENCES quotes(id) ON DELETE CASCADE,
+    views INTEGER NOT NULL DEFAULT 0,
+    likes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS quotes_fts USING fts5(quote, content='quotes', content_rowid='id');
+
+CREATE TRIGGER IF NOT EXISTS quotes_fts_insert AFTER INSERT ON quotes BEGIN
+    INSERT INTO quotes_fts(rowid, quote) VALUES (new.id, new.quote);
+END;
+
+CREATE TRIGGER IF NOT EXISTS quotes_fts_delete AFTER DELETE ON quotes BEGIN
+    INSERT INTO quotes_fts(quotes_fts, rowid, quote) VALUES ('delete', old.id, old.quote);
+END;
+
+CREATE TRIGGER IF NOT EXISTS quotes_fts_update AFTER UPDATE OF quote ON quotes BEGIN
+    INSERT INTO quotes_fts(quotes_fts, rowid, quote) VALUES ('delete', old.id, old.quote);
+    INSERT INTO quotes_fts(rowid, quote) VALUES (new.id, new.quote);
+END;
+
+`
+
+// linkAuthors adds authors for quotes stored before the authors table
+// existed, using the author_key function registered in init.
+const linkAuthors = `
+INSERT OR IGNORE INTO authors(name, name_key)
+SELECT author, author_key(author) FROM quotes WHERE author_id IS NULL ORDER BY id;
+
+UPDATE quotes SET author_id = (SELECT id FROM authors WHERE name_key = author_key(quotes.author))
+WHERE author_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS quotes_author_id_idx ON quotes (author_id);
+`
+
+// upsertAuthor creates the author named ?1 with key ?2 unless the key is
+// taken. It checks first rather than relying on ON CONFLICT, which would still
+// use up an AUTOINCREMENT id.
+const upsertAuthor = `
+	INSERT INTO authors(name, name_key) SELECT ?1, ?2
+	WHERE NOT EXISTS (SELECT 1 FROM authors WHERE name_key = ?2)`
+
+// quoteColumns selects a quote with its tags as a sorted JSON array. The
+// columns are qualified, since quotes_fts has a quote column too.
+const quoteColumns = `quotes.id, quotes.author, quotes.quote, quotes.author_id, quotes.language, (
+	SELECT json_group_array(name) FROM (
+		SELECT t.name FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id
+		WHERE qt.quote_id = quotes.id ORDER BY t.name
+	)
+)`
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("casefold", 1, casefold)
+	sqlite.MustRegisterDeterministicScalarFunction("author_key", 1, authorKey)
+}
+
+// casefold lowercases text with Go's Unicode tables. SQL NULL stays NULL.
+func casefold(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	switch v := args[0].(type) {
+	case string:
+		return strings.ToLower(v), nil
+	case []byte:
+		return strings.ToLower(string(v)), nil
+	}
+
+	return args[0], nil
+}
+
+// authorKey exposes models.AuthorKey to SQL.
+func authorKey(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	switch v := args[0].(type) {
+	case string:
+		return models.AuthorKey(v), nil
+	case []byte:
+		return models.AuthorKey(string(v)), nil
+	}
+
+	return args[0], nil
+}
+
+type Database struct {
+	Db *sql.DB
+}
+
+// New opens the database file at path, creating it and the schema if they
+// don't exist yet.
+func New(path string) (*Database, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+
+	indexed, err := hasTable(db, "quotes_fts")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't read schema: %v", err)
+	}
+
+	if _, err = db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't create schema: %v", err)
+	}
+
+	if err = migrate(db, indexed); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't migrate schema: %v", err)
+	}
+
+	return &Database{
+		Db: db,
+	}, nil
+}
+
+func (d *Database) AddQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
+	const op = "sqlite.AddQuote"
+
+	tx, err := d.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	key := models.AuthorKey(q.Author)
+	if _, err = tx.ExecContext(ctx, upsertAuthor, q.Author, key); err != nil {
+		return nil, fmt.Errorf("%s: failed to add author: %w", op, classify(err))
+	}
+
+	query := `
+		INSERT INTO quotes(author, quote, author_id, language) VALUES (?, ?, (SELECT id FROM authors WHERE name_key = ?), ?)
+		RETURNING id, author, quote, author_id, language`
+
+	row := tx.QueryRowContext(ctx, query, q.Author, q.Quote, key, q.Language)
+
+	var quote models.Quote
+	if err = row.Scan(&quote.Id, &quote.Author, &quote.Quote, &quote.AuthorId, &quote.Language); err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	if err = setTags(ctx, tx, quote.Id, q.Tags); err != nil {
+		return nil, fmt.Errorf("%s: failed to set tags: %w", op, classify(err))
+	}
+	quote.Tags = q.Tags
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) AddQuotes(ctx context.Context, quotes []models.Quote) (int, error) {
+	const op = "sqlite.AddQuotes"
+
+	tx, err := d.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	authorStmt, err := tx.PrepareContext(ctx, upsertAuthor)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare query: %w", op, err)
+	}
+	defer authorStmt.Close()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO quotes(author, quote, author_id, language) VALUES (?, ?, (SELECT id FROM authors WHERE name_key = ?), ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare query: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, q := range quotes {
+		key := models.AuthorKey(q.Author)
+		if _, err = authorStmt.ExecContext(ctx, q.Author, key); err != nil {
+			return 0, fmt.Errorf("%s: failed to add author: %w", op, classify(err))
+		}
+
+		res, err := stmt.ExecContext(ctx, q.Author, q.Quote, key, q.Language)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+		}
+
+		if len(q.Tags) == 0 {
+			continue
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+		}
+
+		if err = setTags(ctx, tx, id, q.Tags); err != nil {
+			return 0, fmt.Errorf("%s: failed to set tags: %w", op, classify(err))
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return len(quotes), nil
+}
+
+func (d *Database) GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (*models.Page, error) {
+	const op = "sqlite.GetQuotes"
+
+	conds, args := filterConditions(f)
+	conds = append(conds, "id > ?")
+	args = append(args, p.After, p.Limit+1)
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY id LIMIT ?`
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	quotes, err := scanQuotes(op, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewPage(quotes, p.Limit), nil
+}
+
+func (d *Database) AllQuotes(ctx context.Context) iter.Seq2[models.Quote, error] {
+	const op = "sqlite.AllQuotes"
+
+	return func(yield func(models.Quote, error) bool) {
+		query := `SELECT ` + quoteColumns + ` FROM quotes ORDER BY id`
+
+		rows, err := d.Db.QueryContext(ctx, query)
+		if err != nil {
+			yield(models.Quote{}, fmt.Errorf("%s: failed to execute query: %w", op, classify(err)))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			quote, err := scanQuote(rows)
+			if err != nil {
+				yield(models.Quote{}, fmt.Errorf("%s: failed to scan row: %w", op, err))
+				return
+			}
+
+			if !yield(quote, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(models.Quote{}, fmt.Errorf("%s: failed to iterate rows: %w", op, err))
+		}
+	}
+}
+
+func (d *Database) GetQuoteByID(ctx context.Context, id string) (*models.Quote, error) {
+	const op = "sqlite.GetQuoteByID"
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes WHERE id = ?`
+
+	row := d.Db.QueryRowContext(ctx, query, id)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &quote, nil
+}
+
+// SearchQuotes matches with the FTS5 index and ranks with bm25. Snippets are
+// built in Go, since FTS5's highlight() doesn't escape the text.
+func (d *Database) SearchQuotes(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	const op = "sqlite.SearchQuotes"
+
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	// Quoting every term keeps FTS5 from reading operators out of user input.
+	match := `"` + strings.Join(terms, `" "`) + `"`
+
+	q := `
+		SELECT ` + quoteColumns + `, -bm25(quotes_fts) AS rank
+		FROM quotes_fts JOIN quotes ON quotes.id = quotes_fts.rowid
+		WHERE quotes_fts MATCH ?
+		ORDER BY rank DESC, quotes.id
+		LIMIT ?`
+
+	rows, err := d.Db.QueryContext(ctx, q, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var r models.SearchResult
+		var err error
+		if r.Quote, err = scanQuote(rows, &r.Rank); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		r.Snippet = search.Highlight(r.Quote.Quote, terms)
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func (d *Database) GetRandomQuote(ctx context.Context, f models.QuoteFilter, r models.RandomRequest) ([]models.Quote, error) {
+	const op = "sqlite.GetRandomQuote"
+
+	if r.Seed != nil {
+		return d.seededQuotes(ctx, f, r.N, *r.Seed)
+	}
+
+	if r.Weight == models.WeightPopular || r.Weight == models.WeightRecent {
+		return d.weightedQuotes(ctx, f, r.N, r.Weight)
+	}
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes`
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY random() LIMIT ?`
+
+	rows, err := d.Db.QueryContext(ctx, query, append(args, r.N)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	quotes, err := scanQuotes(op, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	return quotes, nil
+}
+
+func (d *Database) weightedQuotes(ctx context.Context, f models.QuoteFilter, n int, w models.Weight) ([]models.Quote, error) {
+	const op = "sqlite.GetRandomQuote"
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes`
+
+	weight := `quotes.id`
+	if w == models.WeightPopular {
+		weight = fmt.Sprintf(`(1 + COALESCE(c.views, 0) + %d * COALESCE(c.likes, 0))`, models.LikeViews)
+		query += ` LEFT JOIN quote_counters c ON c.quote_id = quotes.id`
+	}
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	args = append(args, n)
+	// random() is a signed 64-bit integer, which this maps to [0, 1).
+	query += ` ORDER BY -ln(1 - (random() / 18446744073709551616.0 + 0.5)) / ` + weight + ` LIMIT ?`
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	quotes, err := scanQuotes(op, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	return quotes, nil
+}
+
+func (d *Database) seededQuotes(ctx context.Context, f models.QuoteFilter, n int, seed uint64) ([]models.Quote, error) {
+	const op = "sqlite.GetRandomQuote"
+
+	count, err := d.CountQuotes(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if count == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	indexes := models.SeededIndexes(seed, n, count)
+
+	var where string
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		where = ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	for _, i := range indexes {
+		args = append(args, i)
+	}
+
+	query := `
+		SELECT ` + quoteColumns + `, m.i FROM (
+			SELECT quotes.id AS quote_id, row_number() OVER (ORDER BY quotes.id) - 1 AS i FROM quotes` + where + `
+		) m JOIN quotes ON quotes.id = m.quote_id
+		WHERE m.i IN (?` + strings.Repeat(", ?", len(indexes)-1) + `)`
+
+	rows, err := d.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	byIndex := make(map[int]models.Quote, len(indexes))
+	for rows.Next() {
+		var i int
+		quote, err := scanQuote(rows, &i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		byIndex[i] = quote
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	quotes := models.PickIndexed(byIndex, indexes)
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("%s: no quotes: %w", op, repository.ErrNotFound)
+	}
+
+	return quotes, nil
+}
+
+func (d *Database) CountQuotes(ctx context.Context, f models.QuoteFilter) (int, error) {
+	const op = "sqlite.CountQuotes"
+
+	query := `SELECT count(*) FROM quotes`
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+
+	var count int
+	if err := d.Db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return count, nil
+}
+
+func (d *Database) GetQuoteAt(ctx context.Context, f models.QuoteFilter, i int) (*models.Quote, error) {
+	const op = "sqlite.GetQuoteAt"
+
+	query := `SELECT ` + quoteColumns + ` FROM quotes`
+
+	conds, args := filterConditions(f)
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY quotes.id LIMIT 1 OFFSET ?`
+
+	row := d.Db.QueryRowContext(ctx, query, append(args, i)...)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) UpdateQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
+	const op = "sqlite.UpdateQuote"
+
+	tx, err := d.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	key := models.AuthorKey(q.Author)
+	if _, err = tx.ExecContext(ctx, upsertAuthor, q.Author, key); err != nil {
+		return nil, fmt.Errorf("%s: failed to add author: %w", op, classify(err))
+	}
+
+	query := `
+		UPDATE quotes SET author = ?, quote = ?, author_id = (SELECT id FROM authors WHERE name_key = ?), language = ?
+		WHERE id = ? RETURNING id, author, quote, author_id, language`
+
+	row := tx.QueryRowContext(ctx, query, q.Author, q.Quote, key, q.Language, q.Id)
+
+	var quote models.Quote
+	err = row.Scan(&quote.Id, &quote.Author, &quote.Quote, &quote.AuthorId, &quote.Language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	if err = setTags(ctx, tx, quote.Id, q.Tags); err != nil {
+		return nil, fmt.Errorf("%s: failed to set tags: %w", op, classify(err))
+	}
+	quote.Tags = q.Tags
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) PatchQuote(ctx context.Context, id string, p models.QuotePatch) (*models.Quote, error) {
+	const op = "sqlite.PatchQuote"
+
+	tx, err := d.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// A nil key matches no author, which leaves author_id unchanged.
+	var key *string
+	if p.Author != nil {
+		k := models.AuthorKey(*p.Author)
+		key = &k
+
+		if _, err = tx.ExecContext(ctx, upsertAuthor, *p.Author, k); err != nil {
+			return nil, fmt.Errorf("%s: failed to add author: %w", op, classify(err))
+		}
+	}
+
+	query := `
+		UPDATE quotes SET author = COALESCE(?, author), quote = COALESCE(?, quote),
+			author_id = COALESCE((SELECT id FROM authors WHERE name_key = ?), author_id), language = COALESCE(?, language)
+		WHERE id = ? RETURNING ` + quoteColumns
+
+	row := tx.QueryRowContext(ctx, query, p.Author, p.Quote, key, p.Language, id)
+
+	quote, err := scanQuote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	if p.Tags != nil {
+		if err = setTags(ctx, tx, quote.Id, *p.Tags); err != nil {
+			return nil, fmt.Errorf("%s: failed to set tags: %w", op, classify(err))
+		}
+		quote.Tags = *p.Tags
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return &quote, nil
+}
+
+func (d *Database) ViewQuote(ctx context.Context, id string) error {
+	return d.countQuote(ctx, "sqlite.ViewQuote", id, "views")
+}
+
+func (d *Database) LikeQuote(ctx context.Context, id string) error {
+	return d.countQuote(ctx, "sqlite.LikeQuote", id, "likes")
+}
+
+func (d *Database) countQuote(ctx context.Context, op, id, column string) error {
+	query := fmt.Sprintf(`
+		INSERT INTO quote_counters(quote_id, %[1]s) SELECT id, 1 FROM quotes WHERE id = ?
+		ON CONFLICT (quote_id) DO UPDATE SET %[1]s = quote_counters.%[1]s + 1`, column)
+
+	res, err := d.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (d *Database) DeleteQuote(ctx context.Context, id string) error {
+	const op = "sqlite.DeleteQuote"
+
+	query := `DELETE FROM quotes WHERE id = ?`
+
+	res, err := d.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: quote %w", op, repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (d *Database) GetAuthors(ctx context.Context, p models.PageRequest) (*models.AuthorPage, error) {
+	const op = "sqlite.GetAuthors"
+
+	query := `
+		SELECT a.id, a.name, count(*) FROM authors a JOIN quotes q ON q.author_id = a.id
+		WHERE a.id > ? GROUP BY a.id ORDER BY a.id LIMIT ?`
+
+	rows, err := d.Db.QueryContext(ctx, query, p.After, p.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	var authors []models.Author
+	for rows.Next() {
+		var author models.Author
+		if err := rows.Scan(&author.Id, &author.Name, &author.QuoteCount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		authors = append(authors, author)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return models.NewAuthorPage(authors, p.Limit), nil
+}
+
+func (d *Database) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	const op = "sqlite.GetAuthorByID"
+
+	query := `
+		SELECT a.id, a.name, count(*) FROM authors a JOIN quotes q ON q.author_id = a.id
+		WHERE a.id = ? GROUP BY a.id`
+
+	row := d.Db.QueryRowContext(ctx, query, id)
+
+	var author models.Author
+	err := row.Scan(&author.Id, &author.Name, &author.QuoteCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: author %w", op, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, classify(err))
+	}
+
+	return &author, nil
+}
+
+func (d *Database) GetTags(ctx context.Context) ([]models.Tag, error) {
+	const op = "sqlite.GetTags"
+
+	query := `
+		SELECT t.name, count(*) FROM tags t JOIN quote_tags qt ON qt.tag_id = t.id
+		GROUP BY t.id ORDER BY count(*) DESC, t.name`
+
+	rows, err := d.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, classify(err))
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.QuoteCount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+func (d *Database) Close() error {
+	if err := d.Db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	return nil
+}
+
+// migrate brings databases created by earlier versions up to the current
+// schema. Unless quotes_fts existed before the schema was applied, the rows
+// already in quotes are added to it.
+func migrate(db *sql.DB, indexed bool) error {
+	if !indexed {
+		if _, err := db.Exec(`INSERT INTO quotes_fts(quotes_fts) VALUES ('rebuild')`); err != nil {
+			return err
+		}
+	}
+
+	if err := addColumn(db, "author_id", `ALTER TABLE quotes ADD COLUMN author_id INTEGER REFERENCES authors(id)`); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(linkAuthors); err != nil {
+		return err
+	}
+
+	return addColumn(db, "language", `ALTER TABLE quotes ADD COLUMN language TEXT NOT NULL DEFAULT ''`)
+}
+
+// hasTable reports whether the database has a table called name.
+func hasTable(db *sql.DB, name string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT count(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&exists)
+
+	return exists, err
+}
+
+// addColumn runs alter unless the quotes table already has the column.
+func addColumn(db *sql.DB, column, alter string) error {
+	var exists bool
+	err := db.QueryRow(`SELECT count(*) > 0 FROM pragma_table_info('quotes') WHERE name = ?`, column).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = db.Exec(alter)
+
+	return err
+}
+
+func scanQuotes(op string, rows *sql.Rows) ([]models.Quote, error) {
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		quote, err := scanQuote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		quotes = append(quotes, quote)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	return quotes, nil
+}
+
+// scanQuote scans a row selected with quoteColumns followed by extra columns
+// and decodes the JSON array of tags.
+func scanQuote(row interface{ Scan(...any) error }, extra ...any) (models.Quote, error) {
+	var quote models.Quote
+	var tags string
+
+	dest := append([]any{&quote.Id, &quote.Author, &quote.Quote, &quote.AuthorId, &quote.Language, &tags}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.Quote{}, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &quote.Tags); err != nil {
+		return models.Quote{}, err
+	}
+
+	if len(quote.Tags) == 0 {
+		quote.Tags = nil
+	}
+
+	return quote, nil
+}
+
+// setTags replaces the tags of the quote with id, creating the missing ones.
+func setTags(ctx context.Context, tx *sql.Tx, id any, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quote_tags WHERE quote_id = ?`, id); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags(name) VALUES (?)`, tag); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO quote_tags(quote_id, tag_id) SELECT ?, id FROM tags WHERE name = ?`
+		if _, err := tx.ExecContext(ctx, query, id, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// filterConditions returns the WHERE conditions for f with their arguments.
+// SQLite's own case folding only covers ASCII, so case-insensitive author
+// matches compare the casefold of both sides instead.
+func filterConditions(f models.QuoteFilter) ([]string, []any) {
+	var conds []string
+	var args []any
+
+	if f.Author != "" {
+		author := escapeLike(strings.ToLower(f.Author))
+
+		switch f.AuthorMatch {
+		case models.AuthorIExact:
+			conds = append(conds, `casefold(author) LIKE ? ESCAPE '\'`)
+			args = append(args, author)
+		case models.AuthorPrefix:
+			conds = append(conds, `casefold(author) LIKE ? ESCAPE '\'`)
+			args = append(args, author+"%")
+		case models.AuthorContains:
+			conds = append(conds, `casefold(author) LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+author+"%")
+		default:
+			conds = append(conds, "author = ?")
+			args = append(args, f.Author)
+		}
+	}
+
+	if f.AuthorId != "" {
+		conds = append(conds, "author_id = ?")
+		args = append(args, f.AuthorId)
+	}
+
+	if f.Language != "" {
+		conds = append(conds, "language = ?")
+		args = append(args, f.Language)
+	}
+
+	if f.MaxLength > 0 {
+		conds = append(conds, "length(quote) <= ?")
+		args = append(args, f.MaxLength)
+	}
+
+	if len(f.Tags) > 0 {
+		conds = append(conds, `id IN (
+			SELECT qt.quote_id FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id
+			WHERE t.name IN (?`+strings.Repeat(", ?", len(f.Tags)-1)+`) GROUP BY qt.quote_id HAVING count(*) = ?
+		)`)
+		for _, tag := range f.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(f.Tags))
+	}
+
+	return conds, args
+}
+
+// escapeLike escapes the LIKE wildcards in s with a backslash.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// classify wraps the SQLite errors that repository errors stand for, told
+// apart by their extended result codes.
+func classify(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return fmt.Errorf("%w: %w", repository.ErrConflict, err)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %w", repository.ErrInvalid, err)
+	case sqlite3.SQLITE_INTERRUPT:
+		return fmt.Errorf("%w: %w", repository.ErrCanceled, err)
+	}
+
+	return err
+}
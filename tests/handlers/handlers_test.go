@@ -2,14 +2,20 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	handlers2 "github.com/odysseymorphey/quotes-service/internal/handlers"
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"iter"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/odysseymorphey/quotes-service/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -18,11 +24,13 @@ import (
 
 func TestBaseHandler_AddQuote(t *testing.T) {
 	tests := []struct {
-		name         string
-		requestBody  interface{}
-		mockError    error
-		expectedCode int
-		expectedBody string
+		name             string
+		requestBody      interface{}
+		invalid          bool
+		mockError        error
+		expectedCode     int
+		expectedBody     string
+		expectedLocation string
 	}{
 		{
 			name: "successful add",
@@ -30,7 +38,9 @@ func TestBaseHandler_AddQuote(t *testing.T) {
 				Author: "Test Author",
 				Quote:  "Test Quote",
 			},
-			expectedCode: http.StatusOK,
+			expectedCode:     http.StatusCreated,
+			expectedBody:     `{"id":"7","author":"Test Author","quote":"Test Quote"}` + "\n",
+			expectedLocation: "/quotes/7",
 		},
 		{
 			name:         "invalid json",
@@ -48,6 +58,47 @@ func TestBaseHandler_AddQuote(t *testing.T) {
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: "Internal server error\n",
 		},
+		{
+			name: "repository conflict",
+			requestBody: models.Quote{
+				Author: "Test Author",
+				Quote:  "Test Quote",
+			},
+			mockError:    fmt.Errorf("postgres.AddQuote: %w", repository.ErrConflict),
+			expectedCode: http.StatusConflict,
+			expectedBody: "Conflict\n",
+		},
+		{
+			name: "repository rejects data",
+			requestBody: models.Quote{
+				Author: "Test Author",
+				Quote:  "Test Quote",
+			},
+			mockError:    fmt.Errorf("postgres.AddQuote: %w", repository.ErrInvalid),
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: "Unprocessable entity\n",
+		},
+		{
+			name:         "empty fields",
+			requestBody:  models.Quote{Author: "  ", Quote: ""},
+			invalid:      true,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"author","message":"is required"},{"field":"quote","message":"is required"}]}` + "\n",
+		},
+		{
+			name:         "author too long",
+			requestBody:  models.Quote{Author: strings.Repeat("a", models.MaxAuthorLength+1), Quote: "Test Quote"},
+			invalid:      true,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"author","message":"must be at most 30 characters"}]}` + "\n",
+		},
+		{
+			name:         "unknown field",
+			requestBody:  map[string]string{"author": "Test Author", "quote": "Test Quote", "source": "book"},
+			invalid:      true,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"source","message":"unknown field"}]}` + "\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -61,21 +112,25 @@ func TestBaseHandler_AddQuote(t *testing.T) {
 
 			if tt.mockError != nil {
 				mockRepo.On("AddQuote", mock.Anything, mock.AnythingOfType("models.Quote")).
-					Return(tt.mockError)
-			} else if tt.requestBody != "invalid json" {
+					Return((*models.Quote)(nil), tt.mockError)
+			} else if tt.requestBody != "invalid json" && !tt.invalid {
 				mockRepo.On("AddQuote", mock.Anything, mock.AnythingOfType("models.Quote")).
-					Return(nil)
+					Return(func(q models.Quote) *models.Quote {
+						q.Id = "7"
+						return &q
+					}(tt.requestBody.(models.Quote)), nil)
 			}
 
 			handler.AddQuote(rr, req)
 
 			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedLocation, rr.Header().Get("Location"))
 
 			if tt.expectedBody != "" {
 				assert.Equal(t, tt.expectedBody, rr.Body.String())
 			}
 
-			if tt.mockError == nil && tt.requestBody != "invalid json" {
+			if tt.mockError == nil && tt.requestBody != "invalid json" && !tt.invalid {
 				mockRepo.AssertCalled(t, "AddQuote", mock.Anything, mock.MatchedBy(func(q models.Quote) bool {
 					return q.Author == tt.requestBody.(models.Quote).Author &&
 						q.Quote == tt.requestBody.(models.Quote).Quote
@@ -86,6 +141,66 @@ func TestBaseHandler_AddQuote(t *testing.T) {
 	}
 }
 
+func TestBaseHandler_AddQuote_TrailingData(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "garbage",
+			body:         `{"author":"A","quote":"q"} garbage`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "second value",
+			body:         `{"author":"A","quote":"q"}{"author":"B","quote":"r"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "trailing whitespace",
+			body:         `{"author":"A","quote":"q"}` + "\n\t ",
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.expectedCode == http.StatusCreated {
+				mockRepo.On("AddQuote", mock.Anything, models.Quote{Author: "A", Quote: "q"}).
+					Return(&models.Quote{Id: "1", Author: "A", Quote: "q"}, nil)
+			}
+
+			req := httptest.NewRequest("POST", "/quotes", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			handler.AddQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_AddQuote_TooLarge(t *testing.T) {
+	mockRepo := new(MockRepository)
+	handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+	body, _ := json.Marshal(models.Quote{Author: "Test Author", Quote: strings.Repeat("a", 100)})
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/quotes", bytes.NewReader(body))
+	req.Body = http.MaxBytesReader(rr, req.Body, 64)
+
+	handler.AddQuote(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, "Request body is larger than 64 bytes\n", rr.Body.String())
+	mockRepo.AssertNotCalled(t, "AddQuote", mock.Anything, mock.Anything)
+}
+
 func TestBaseHandler_DeleteQuote(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -110,9 +225,9 @@ func TestBaseHandler_DeleteQuote(t *testing.T) {
 		{
 			name:         "not found",
 			id:           "non-existent-id",
-			mockError:    fmt.Errorf("postgres.DeleteQuote: quote not found"),
-			expectedCode: http.StatusInternalServerError,
-			expectedBody: "Internal server error\n",
+			mockError:    fmt.Errorf("postgres.DeleteQuote: quote %w", repository.ErrNotFound),
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Quote not found\n",
 		},
 		{
 			name:         "empty id",
@@ -161,10 +276,15 @@ func TestBaseHandler_GetQuotes(t *testing.T) {
 		{Id: "2", Author: "Author2", Quote: "Quote2"},
 	}
 
+	firstPage := models.PageRequest{Limit: models.DefaultPageLimit}
+	noFilter := models.QuoteFilter{AuthorMatch: models.AuthorExact}
+
 	tests := []struct {
 		name           string
 		queryParams    map[string]string
-		mockQuotes     []models.Quote
+		expectedFilter models.QuoteFilter
+		expectedPage   models.PageRequest
+		mockPage       *models.Page
 		mockError      error
 		expectedCode   int
 		expectedBody   string
@@ -172,37 +292,137 @@ func TestBaseHandler_GetQuotes(t *testing.T) {
 	}{
 		{
 			name:           "get all quotes successfully",
-			mockQuotes:     mockQuotes,
+			expectedFilter: noFilter,
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: mockQuotes},
 			expectedCode:   http.StatusOK,
-			expectedBody:   `[{"id":"1","author":"Author1","quote":"Quote1"},{"id":"2","author":"Author2","quote":"Quote2"}]` + "\n",
+			expectedBody:   `{"quotes":[{"id":"1","author":"Author1","quote":"Quote1"},{"id":"2","author":"Author2","quote":"Quote2"}]}` + "\n",
 			expectedHeader: "application/json",
 		},
 		{
 			name:           "get quotes by author",
 			queryParams:    map[string]string{"author": "Author1"},
-			mockQuotes:     []models.Quote{mockQuotes[0]},
+			expectedFilter: models.QuoteFilter{Author: "Author1", AuthorMatch: models.AuthorExact},
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: []models.Quote{mockQuotes[0]}},
 			expectedCode:   http.StatusOK,
-			expectedBody:   `[{"id":"1","author":"Author1","quote":"Quote1"}]` + "\n",
+			expectedBody:   `{"quotes":[{"id":"1","author":"Author1","quote":"Quote1"}]}` + "\n",
 			expectedHeader: "application/json",
 		},
 		{
-			name:         "error getting all quotes",
-			mockError:    errors.New("database error"),
-			expectedCode: http.StatusInternalServerError,
-			expectedBody: "Internal server error\n",
+			name:           "get quotes by partial author",
+			queryParams:    map[string]string{"author": "auth", "author_match": "prefix"},
+			expectedFilter: models.QuoteFilter{Author: "auth", AuthorMatch: models.AuthorPrefix},
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: mockQuotes},
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"quotes":[{"id":"1","author":"Author1","quote":"Quote1"},{"id":"2","author":"Author2","quote":"Quote2"}]}` + "\n",
+			expectedHeader: "application/json",
 		},
 		{
-			name:         "error getting quotes by author",
-			queryParams:  map[string]string{"author": "Unknown"},
-			mockError:    errors.New("not found"),
-			expectedCode: http.StatusInternalServerError,
-			expectedBody: "Internal server error\n",
+			name:           "get quotes by tag",
+			queryParams:    map[string]string{"tag": " Humor "},
+			expectedFilter: models.QuoteFilter{AuthorMatch: models.AuthorExact, Tags: []string{"humor"}},
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: []models.Quote{{Id: "1", Author: "Author1", Quote: "Quote1", Tags: []string{"humor"}}}},
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"quotes":[{"id":"1","author":"Author1","quote":"Quote1","tags":["humor"]}]}` + "\n",
+			expectedHeader: "application/json",
+		},
+		{
+			name:           "get quotes by language and length",
+			queryParams:    map[string]string{"language": "ru", "max_length": "140"},
+			expectedFilter: models.QuoteFilter{AuthorMatch: models.AuthorExact, Language: "ru", MaxLength: 140},
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: []models.Quote{}},
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"quotes":[]}` + "\n",
+			expectedHeader: "application/json",
+		},
+		{
+			name:         "invalid max length",
+			queryParams:  map[string]string{"max_length": "-1"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid max_length\n",
+		},
+		{
+			name:         "invalid author match",
+			queryParams:  map[string]string{"author": "auth", "author_match": "fuzzy"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid author_match\n",
+		},
+		{
+			name:           "limit and cursor",
+			queryParams:    map[string]string{"limit": "1", "cursor": models.EncodeCursor("1")},
+			expectedFilter: noFilter,
+			expectedPage:   models.PageRequest{After: 1, Limit: 1},
+			mockPage:       &models.Page{Quotes: []models.Quote{mockQuotes[1]}, NextCursor: models.EncodeCursor("2")},
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"quotes":[{"id":"2","author":"Author2","quote":"Quote2"}],"next_cursor":"` + models.EncodeCursor("2") + `"}` + "\n",
+			expectedHeader: "application/json",
+		},
+		{
+			name:           "limit is clamped",
+			queryParams:    map[string]string{"limit": "1000"},
+			expectedFilter: noFilter,
+			expectedPage:   models.PageRequest{Limit: models.MaxPageLimit},
+			mockPage:       &models.Page{Quotes: []models.Quote{}},
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"quotes":[]}` + "\n",
+			expectedHeader: "application/json",
+		},
+		{
+			name:         "invalid limit",
+			queryParams:  map[string]string{"limit": "0"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid limit\n",
+		},
+		{
+			name:         "invalid cursor",
+			queryParams:  map[string]string{"cursor": "not a cursor"},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid cursor\n",
+		},
+		{
+			name:           "error getting all quotes",
+			expectedFilter: noFilter,
+			expectedPage:   firstPage,
+			mockError:      errors.New("database error"),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   "Internal server error\n",
+		},
+		{
+			name:           "error getting quotes by author",
+			queryParams:    map[string]string{"author": "Unknown"},
+			expectedFilter: models.QuoteFilter{Author: "Unknown", AuthorMatch: models.AuthorExact},
+			expectedPage:   firstPage,
+			mockError:      errors.New("not found"),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   "Internal server error\n",
+		},
+		{
+			name:           "request deadline exceeded",
+			expectedFilter: noFilter,
+			expectedPage:   firstPage,
+			mockError:      fmt.Errorf("memory.GetQuotes: %w", context.DeadlineExceeded),
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedBody:   "Request timed out\n",
+		},
+		{
+			name:           "query canceled",
+			expectedFilter: noFilter,
+			expectedPage:   firstPage,
+			mockError:      fmt.Errorf("postgres.GetQuotes: %w", repository.ErrCanceled),
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedBody:   "Request timed out\n",
 		},
 		{
 			name:           "empty quotes list",
-			mockQuotes:     []models.Quote{},
+			expectedFilter: noFilter,
+			expectedPage:   firstPage,
+			mockPage:       &models.Page{Quotes: []models.Quote{}},
 			expectedCode:   http.StatusOK,
-			expectedBody:   "[]\n",
+			expectedBody:   `{"quotes":[]}` + "\n",
 			expectedHeader: "application/json",
 		},
 	}
@@ -221,12 +441,9 @@ func TestBaseHandler_GetQuotes(t *testing.T) {
 
 			rr := httptest.NewRecorder()
 
-			if author := tt.queryParams["author"]; author != "" {
-				mockRepo.On("GetQuotesByAuthor", mock.Anything, author).
-					Return(tt.mockQuotes, tt.mockError)
-			} else {
-				mockRepo.On("GetQuotes", mock.Anything).
-					Return(tt.mockQuotes, tt.mockError)
+			if tt.expectedCode != http.StatusBadRequest {
+				mockRepo.On("GetQuotes", mock.Anything, tt.expectedFilter, tt.expectedPage).
+					Return(tt.mockPage, tt.mockError)
 			}
 
 			handler.GetQuotes(rr, req)
@@ -235,6 +452,9 @@ func TestBaseHandler_GetQuotes(t *testing.T) {
 			if tt.expectedBody != "" {
 				assert.Equal(t, tt.expectedBody, rr.Body.String())
 			}
+			if tt.expectedHeader != "" {
+				assert.Equal(t, tt.expectedHeader, rr.Header().Get("Content-Type"))
+			}
 
 			mockRepo.AssertExpectations(t)
 		})
@@ -242,6 +462,228 @@ func TestBaseHandler_GetQuotes(t *testing.T) {
 }
 
 func TestBaseHandler_GetRandomQuote(t *testing.T) {
+	mockQuotes := []models.Quote{
+		{Id: "1", Author: "Test Author", Quote: "Test Quote"},
+		{Id: "2", Author: "Test Author", Quote: "Another Quote", Language: "en"},
+	}
+
+	noFilter := models.QuoteFilter{AuthorMatch: models.AuthorExact}
+	seed := models.ParseSeed("card-42")
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedFilter  models.QuoteFilter
+		expectedRequest models.RandomRequest
+		mockQuotes      []models.Quote
+		mockError       error
+		expectedCode    int
+		expectedBody    string
+		expectedHeader  string
+	}{
+		{
+			name:            "successful get random quote",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockQuotes:      mockQuotes[:1],
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"id":"1","author":"Test Author","quote":"Test Quote"}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:            "random quote by tags",
+			query:           "?tag=Stoicism&tag=humor&tag=humor",
+			expectedFilter:  models.QuoteFilter{AuthorMatch: models.AuthorExact, Tags: []string{"humor", "stoicism"}},
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockQuotes:      []models.Quote{{Id: "1", Author: "Test Author", Quote: "Test Quote", Tags: []string{"humor", "stoicism"}}},
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"id":"1","author":"Test Author","quote":"Test Quote","tags":["humor","stoicism"]}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:  "random quote by author, language and length",
+			query: "?author=test&author_match=prefix&language=EN&max_length=20",
+			expectedFilter: models.QuoteFilter{
+				Author:      "test",
+				AuthorMatch: models.AuthorPrefix,
+				Language:    "en",
+				MaxLength:   20,
+			},
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockQuotes:      mockQuotes[1:],
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"id":"2","author":"Test Author","quote":"Another Quote","language":"en"}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:            "several random quotes",
+			query:           "?n=3",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 3, Weight: models.WeightUniform},
+			mockQuotes:      mockQuotes,
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"quotes":[{"id":"1","author":"Test Author","quote":"Test Quote"},{"id":"2","author":"Test Author","quote":"Another Quote","language":"en"}]}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:            "n is clamped",
+			query:           "?n=1000",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 100, Weight: models.WeightUniform},
+			mockQuotes:      mockQuotes[:1],
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"quotes":[{"id":"1","author":"Test Author","quote":"Test Quote"}]}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:            "seeded random quotes",
+			query:           "?n=2&seed=card-42",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 2, Seed: &seed, Weight: models.WeightUniform},
+			mockQuotes:      mockQuotes,
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"quotes":[{"id":"1","author":"Test Author","quote":"Test Quote"},{"id":"2","author":"Test Author","quote":"Another Quote","language":"en"}]}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:            "popular random quote",
+			query:           "?weight=popular",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightPopular},
+			mockQuotes:      mockQuotes[:1],
+			expectedCode:    http.StatusOK,
+			expectedBody:    `{"id":"1","author":"Test Author","quote":"Test Quote"}` + "\n",
+			expectedHeader:  "application/json",
+		},
+		{
+			name:           "invalid weight",
+			query:          "?weight=heavy",
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   "invalid weight\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+		{
+			name:           "weight with seed",
+			query:          "?weight=recent&seed=card-42",
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   "weight must be uniform with seed\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+		{
+			name:           "invalid n",
+			query:          "?n=0",
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   "invalid n\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+		{
+			name:           "invalid max length",
+			query:          "?max_length=short",
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   "invalid max_length\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "repository error",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockError:       errors.New("database error"),
+			expectedCode:    http.StatusInternalServerError,
+			expectedBody:    "Internal server error\n",
+			expectedHeader:  "text/plain; charset=utf-8",
+		},
+		{
+			name:            "no quotes found",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockError:       fmt.Errorf("postgres.GetRandomQuote: no quotes: %w", repository.ErrNotFound),
+			expectedCode:    http.StatusNotFound,
+			expectedBody:    "No quotes found\n",
+			expectedHeader:  "text/plain; charset=utf-8",
+		},
+		{
+			name:            "no quotes without error",
+			expectedFilter:  noFilter,
+			expectedRequest: models.RandomRequest{N: 1, Weight: models.WeightUniform},
+			mockQuotes:      []models.Quote{},
+			expectedCode:    http.StatusNotFound,
+			expectedBody:    "No quotes found\n",
+			expectedHeader:  "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.expectedCode != http.StatusBadRequest {
+				mockRepo.On("GetRandomQuote", mock.Anything, tt.expectedFilter, tt.expectedRequest).
+					Return(tt.mockQuotes, tt.mockError)
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/random"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetRandomQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedHeader, rr.Header().Get("Content-Type"))
+
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_LikeQuote(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful like",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "not found",
+			mockError:    fmt.Errorf("postgres.LikeQuote: quote %w", repository.ErrNotFound),
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Quote not found\n",
+		},
+		{
+			name:         "repository error",
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			mockRepo.On("LikeQuote", mock.Anything, "1").Return(tt.mockError)
+
+			req := httptest.NewRequest("POST", "/quotes/1/like", nil)
+			req.SetPathValue("id", "1")
+			rr := httptest.NewRecorder()
+
+			handler.LikeQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetQuoteByID(t *testing.T) {
 	mockQuote := &models.Quote{
 		Id:     "1",
 		Author: "Test Author",
@@ -250,38 +692,49 @@ func TestBaseHandler_GetRandomQuote(t *testing.T) {
 
 	tests := []struct {
 		name           string
+		method         string
 		mockQuote      *models.Quote
 		mockError      error
+		viewError      error
 		expectedCode   int
 		expectedBody   string
 		expectedHeader string
 	}{
 		{
-			name:           "successful get random quote",
+			name:           "successful get quote",
 			mockQuote:      mockQuote,
 			expectedCode:   http.StatusOK,
 			expectedBody:   `{"id":"1","author":"Test Author","quote":"Test Quote"}` + "\n",
 			expectedHeader: "application/json",
 		},
 		{
-			name:           "repository error",
-			mockError:      errors.New("database error"),
-			expectedCode:   http.StatusInternalServerError,
-			expectedBody:   "Internal server error\n",
-			expectedHeader: "text/plain; charset=utf-8",
+			name:           "view count error is ignored",
+			mockQuote:      mockQuote,
+			viewError:      errors.New("database error"),
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"id":"1","author":"Test Author","quote":"Test Quote"}` + "\n",
+			expectedHeader: "application/json",
+		},
+		{
+			name:           "head is not counted",
+			method:         http.MethodHead,
+			mockQuote:      mockQuote,
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"id":"1","author":"Test Author","quote":"Test Quote"}` + "\n",
+			expectedHeader: "application/json",
 		},
 		{
-			name:           "no quotes found",
-			mockError:      sql.ErrNoRows,
+			name:           "not found",
+			mockError:      repository.ErrNotFound,
 			expectedCode:   http.StatusNotFound,
-			expectedBody:   "No quotes found\n",
+			expectedBody:   "Quote not found\n",
 			expectedHeader: "text/plain; charset=utf-8",
 		},
 		{
-			name:           "nil quote without error",
-			mockQuote:      nil,
-			expectedCode:   http.StatusNotFound,
-			expectedBody:   "No quotes found\n",
+			name:           "repository error",
+			mockError:      errors.New("database error"),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   "Internal server error\n",
 			expectedHeader: "text/plain; charset=utf-8",
 		},
 	}
@@ -291,20 +744,961 @@ func TestBaseHandler_GetRandomQuote(t *testing.T) {
 			mockRepo := new(MockRepository)
 			handler := &handlers2.BaseHandler{Repo: mockRepo}
 
-			mockRepo.On("GetRandomQuote", mock.Anything).
+			mockRepo.On("GetQuoteByID", mock.Anything, "1").
 				Return(tt.mockQuote, tt.mockError)
+			if tt.mockError == nil && tt.method != http.MethodHead {
+				mockRepo.On("ViewQuote", mock.Anything, "1").Return(tt.viewError)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
 
-			req := httptest.NewRequest("GET", "/quotes/random", nil)
+			req := httptest.NewRequest(method, "/quotes/1", nil)
+			req.SetPathValue("id", "1")
 			rr := httptest.NewRecorder()
 
-			handler.GetRandomQuote(rr, req)
+			handler.GetQuoteByID(rr, req)
 
 			assert.Equal(t, tt.expectedCode, rr.Code)
 			assert.Equal(t, tt.expectedHeader, rr.Header().Get("Content-Type"))
-
-			if tt.expectedBody != "" {
-				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+			if tt.method == http.MethodHead {
+				mockRepo.AssertNotCalled(t, "ViewQuote", mock.Anything, mock.Anything)
 			}
 		})
 	}
 }
+
+func TestBaseHandler_UpdateQuote(t *testing.T) {
+	updated := &models.Quote{Id: "1", Author: "New Author", Quote: "New Quote"}
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockQuote    *models.Quote
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful update",
+			requestBody:  `{"author":"New Author","quote":"New Quote"}`,
+			mockQuote:    updated,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":"1","author":"New Author","quote":"New Quote"}` + "\n",
+		},
+		{
+			name:         "invalid json",
+			requestBody:  `{`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request\n",
+		},
+		{
+			name:         "missing field",
+			requestBody:  `{"author":"New Author"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"quote","message":"is required"}]}` + "\n",
+		},
+		{
+			name:         "whitespace is normalized",
+			requestBody:  `{"author":"  New   Author ","quote":"New Quote\n"}`,
+			mockQuote:    updated,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":"1","author":"New Author","quote":"New Quote"}` + "\n",
+		},
+		{
+			name:         "not found",
+			requestBody:  `{"author":"New Author","quote":"New Quote"}`,
+			mockError:    repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Quote not found\n",
+		},
+		{
+			name:         "repository error",
+			requestBody:  `{"author":"New Author","quote":"New Quote"}`,
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.expectedCode != http.StatusBadRequest && tt.expectedCode != http.StatusUnprocessableEntity {
+				mockRepo.On("UpdateQuote", mock.Anything, models.Quote{Id: "1", Author: "New Author", Quote: "New Quote"}).
+					Return(tt.mockQuote, tt.mockError)
+			}
+
+			req := httptest.NewRequest("PUT", "/quotes/1", bytes.NewBufferString(tt.requestBody))
+			req.SetPathValue("id", "1")
+			rr := httptest.NewRecorder()
+
+			handler.UpdateQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_PatchQuote(t *testing.T) {
+	author := "New Author"
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockQuote    *models.Quote
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful patch",
+			requestBody:  `{"author":"New Author"}`,
+			mockQuote:    &models.Quote{Id: "1", Author: "New Author", Quote: "Old Quote"},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":"1","author":"New Author","quote":"Old Quote"}` + "\n",
+		},
+		{
+			name:         "invalid json",
+			requestBody:  `[]`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request\n",
+		},
+		{
+			name:         "empty patch",
+			requestBody:  `{}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"message":"at least one of author, quote, language, tags is required"}]}` + "\n",
+		},
+		{
+			name:         "unknown field",
+			requestBody:  `{"author":"New Author","likes":5}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"field":"likes","message":"unknown field"}]}` + "\n",
+		},
+		{
+			name:         "not found",
+			requestBody:  `{"author":"New Author"}`,
+			mockError:    repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Quote not found\n",
+		},
+		{
+			name:         "repository error",
+			requestBody:  `{"author":"New Author"}`,
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.expectedCode != http.StatusBadRequest && tt.expectedCode != http.StatusUnprocessableEntity {
+				mockRepo.On("PatchQuote", mock.Anything, "1", models.QuotePatch{Author: &author}).
+					Return(tt.mockQuote, tt.mockError)
+			}
+
+			req := httptest.NewRequest("PATCH", "/quotes/1", bytes.NewBufferString(tt.requestBody))
+			req.SetPathValue("id", "1")
+			rr := httptest.NewRecorder()
+
+			handler.PatchQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_AddQuotesBulk(t *testing.T) {
+	quotes := []models.Quote{
+		{Author: "Author1", Quote: "Quote1"},
+		{Author: "Author2", Quote: "Quote2"},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		contentType  string
+		requestBody  string
+		maxBody      int64
+		canceled     bool
+		setup        func(m *MockRepository)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			requestBody: `[{"author":"Author1","quote":"Quote1"},{"author":" Author2 ","quote":"Quote2"}]`,
+			setup: func(m *MockRepository) {
+				m.On("AddQuotes", mock.Anything, quotes).Return(2, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"created":2}` + "\n",
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			requestBody: "{\"author\":\"Author1\",\"quote\":\"Quote1\"}\n{\"author\":\"Author2\",\"quote\":\"Quote2\"}\n",
+			setup: func(m *MockRepository) {
+				m.On("AddQuotes", mock.Anything, quotes).Return(2, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"created":2}` + "\n",
+		},
+		{
+			name:         "atomic with invalid items",
+			requestBody:  `[{"author":"Author1","quote":"Quote1"},{"author":"Author2"},"quote"]`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"created":0,"results":[` +
+				`{"index":1,"status":"invalid","errors":[{"field":"quote","message":"is required"}]},` +
+				`{"index":2,"status":"invalid","errors":[{"message":"must be a quote object"}]}]}` + "\n",
+		},
+		{
+			name:        "partial",
+			query:       "?mode=partial",
+			requestBody: `[{"author":"Author1","quote":"Quote1"},{"author":"Author2","quote":"Quote2","x":1},{"author":"Author2","quote":"Quote2"}]`,
+			setup: func(m *MockRepository) {
+				m.On("AddQuote", mock.Anything, quotes[0]).Return(&models.Quote{Id: "1", Author: "Author1", Quote: "Quote1"}, nil)
+				m.On("AddQuote", mock.Anything, quotes[1]).Return((*models.Quote)(nil), errors.New("database error"))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"created":1,"results":[` +
+				`{"index":0,"status":"created","quote":{"id":"1","author":"Author1","quote":"Quote1"}},` +
+				`{"index":1,"status":"invalid","errors":[{"field":"x","message":"unknown field"}]},` +
+				`{"index":2,"status":"failed","errors":[{"message":"can't store quote"}]}]}` + "\n",
+		},
+		{
+			name:        "partial with rejected items",
+			query:       "?mode=partial",
+			requestBody: `[{"author":"Author1","quote":"Quote1"},{"author":"Author2","quote":"Quote2"}]`,
+			setup: func(m *MockRepository) {
+				m.On("AddQuote", mock.Anything, quotes[0]).Return((*models.Quote)(nil), fmt.Errorf("postgres.AddQuote: %w", repository.ErrConflict))
+				m.On("AddQuote", mock.Anything, quotes[1]).Return((*models.Quote)(nil), fmt.Errorf("postgres.AddQuote: %w", repository.ErrInvalid))
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"created":0,"results":[` +
+				`{"index":0,"status":"failed","errors":[{"message":"conflicts with a stored quote"}]},` +
+				`{"index":1,"status":"invalid","errors":[{"message":"rejected by the storage"}]}]}` + "\n",
+		},
+		{
+			name:        "partial stops when the storage gives up",
+			query:       "?mode=partial",
+			requestBody: `[{"author":"Author1","quote":"Quote1"},{"author":"Author2","quote":"Quote2"}]`,
+			setup: func(m *MockRepository) {
+				m.On("AddQuote", mock.Anything, quotes[0]).Return((*models.Quote)(nil), fmt.Errorf("postgres.AddQuote: %w", repository.ErrCanceled))
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: "Request timed out\n",
+		},
+		{
+			name:         "partial with canceled request",
+			query:        "?mode=partial",
+			requestBody:  `[{"author":"Author1","quote":"Quote1"},{"author":"Author2","quote":"Quote2"}]`,
+			canceled:     true,
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: "Request timed out\n",
+		},
+		{
+			name:         "repository error",
+			requestBody:  `[{"author":"Author1","quote":"Quote1"}]`,
+			expectedCode: http.StatusInternalServerError,
+			setup: func(m *MockRepository) {
+				m.On("AddQuotes", mock.Anything, quotes[:1]).Return(0, errors.New("database error"))
+			},
+			expectedBody: "Internal server error\n",
+		},
+		{
+			name:         "empty",
+			requestBody:  `[]`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":[{"message":"at least one quote is required"}]}` + "\n",
+		},
+		{
+			name:         "not an array",
+			requestBody:  `{"author":"Author1","quote":"Quote1"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request\n",
+		},
+		{
+			name:         "broken ndjson",
+			contentType:  "application/x-ndjson",
+			requestBody:  "{\"author\":\"Author1\",\"quote\":\"Quote1\"}\n{\"author\":",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid request\n",
+		},
+		{
+			name:         "too many quotes",
+			contentType:  "application/x-ndjson",
+			requestBody:  strings.Repeat("{}\n", models.MaxBulkQuotes+1),
+			expectedCode: http.StatusRequestEntityTooLarge,
+			expectedBody: "at most 10000 quotes per import\n",
+		},
+		{
+			name:         "body too large",
+			requestBody:  `[{"author":"Author1","quote":"Quote1"},{"author":"Author2","quote":"Quote2"}]`,
+			maxBody:      32,
+			expectedCode: http.StatusRequestEntityTooLarge,
+			expectedBody: "Request body is larger than 32 bytes\n",
+		},
+		{
+			name:         "invalid mode",
+			query:        "?mode=yolo",
+			requestBody:  `[]`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid mode\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.setup != nil {
+				tt.setup(mockRepo)
+			}
+
+			req := httptest.NewRequest("POST", "/quotes/bulk"+tt.query, strings.NewReader(tt.requestBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			if tt.maxBody > 0 {
+				req.Body = http.MaxBytesReader(rr, req.Body, tt.maxBody)
+			}
+			if tt.canceled {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+
+			handler.AddQuotesBulk(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_ExportQuotes(t *testing.T) {
+	quotes := func(err error, qs ...models.Quote) iter.Seq2[models.Quote, error] {
+		return func(yield func(models.Quote, error) bool) {
+			for _, q := range qs {
+				if !yield(q, nil) {
+					return
+				}
+			}
+			if err != nil {
+				yield(models.Quote{}, err)
+			}
+		}
+	}
+
+	mockQuotes := []models.Quote{
+		{Id: "1", Author: "Author1", Quote: "Quote1"},
+		{Id: "2", Author: "Author, Jr.", Quote: `He said "hi"`, AuthorId: "7", Language: "en", Tags: []string{"life, death", "wisdom"}},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockQuotes     iter.Seq2[models.Quote, error]
+		expectedCode   int
+		expectedBody   string
+		expectedHeader string
+	}{
+		{
+			name:           "ndjson by default",
+			mockQuotes:     quotes(nil, mockQuotes...),
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"id":"1","author":"Author1","quote":"Quote1"}` + "\n" + `{"id":"2","author":"Author, Jr.","quote":"He said \"hi\"","author_id":"7","language":"en","tags":["life, death","wisdom"]}` + "\n",
+			expectedHeader: "application/x-ndjson",
+		},
+		{
+			name:           "csv",
+			query:          "?format=csv",
+			mockQuotes:     quotes(nil, mockQuotes...),
+			expectedCode:   http.StatusOK,
+			expectedBody:   "id,author,quote,author_id,language,tags\n1,Author1,Quote1,,,\n2,\"Author, Jr.\",\"He said \"\"hi\"\"\",7,en,\"[\"\"life, death\"\",\"\"wisdom\"\"]\"\n",
+			expectedHeader: "text/csv; charset=utf-8",
+		},
+		{
+			name:           "empty csv",
+			query:          "?format=csv",
+			mockQuotes:     quotes(nil),
+			expectedCode:   http.StatusOK,
+			expectedBody:   "id,author,quote,author_id,language,tags\n",
+			expectedHeader: "text/csv; charset=utf-8",
+		},
+		{
+			name:           "error before first row",
+			mockQuotes:     quotes(errors.New("database error")),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   "Internal server error\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+		{
+			name:           "error mid-stream",
+			mockQuotes:     quotes(errors.New("database error"), mockQuotes[0]),
+			expectedCode:   http.StatusOK,
+			expectedBody:   `{"id":"1","author":"Author1","quote":"Quote1"}` + "\n",
+			expectedHeader: "application/x-ndjson",
+		},
+		{
+			name:           "invalid format",
+			query:          "?format=xml",
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   "Invalid format\n",
+			expectedHeader: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.mockQuotes != nil {
+				mockRepo.On("AllQuotes", mock.Anything).Return(tt.mockQuotes)
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/export"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.ExportQuotes(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedHeader, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_SearchQuotes(t *testing.T) {
+	results := []models.SearchResult{
+		{
+			Quote:   models.Quote{Id: "1", Author: "Test Author", Quote: "Test Quote"},
+			Rank:    0.5,
+			Snippet: "<mark>Test</mark> Quote",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		mockQuery    string
+		mockLimit    int
+		mockResults  []models.SearchResult
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful search",
+			url:          "/quotes/search?q=test",
+			mockQuery:    "test",
+			mockLimit:    models.DefaultPageLimit,
+			mockResults:  results,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"results":[{"id":"1","author":"Test Author","quote":"Test Quote","rank":0.5,"snippet":"\u003cmark\u003eTest\u003c/mark\u003e Quote"}]}` + "\n",
+		},
+		{
+			name:         "no matches",
+			url:          "/quotes/search?q=nothing&limit=5",
+			mockQuery:    "nothing",
+			mockLimit:    5,
+			mockResults:  []models.SearchResult{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"results":[]}` + "\n",
+		},
+		{
+			name:         "missing query",
+			url:          "/quotes/search?q=++",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Missing search query\n",
+		},
+		{
+			name:         "invalid limit",
+			url:          "/quotes/search?q=test&limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid limit\n",
+		},
+		{
+			name:         "repository error",
+			url:          "/quotes/search?q=test",
+			mockQuery:    "test",
+			mockLimit:    models.DefaultPageLimit,
+			mockResults:  []models.SearchResult(nil),
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.mockQuery != "" {
+				mockRepo.On("SearchQuotes", mock.Anything, tt.mockQuery, tt.mockLimit).
+					Return(tt.mockResults, tt.mockError)
+			}
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			handler.SearchQuotes(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetAuthors(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		expectedPage models.PageRequest
+		mockPage     *models.AuthorPage
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful get authors",
+			url:          "/authors?limit=1",
+			expectedPage: models.PageRequest{Limit: 1},
+			mockPage: &models.AuthorPage{
+				Authors:    []models.Author{{Id: "1", Name: "Author1", QuoteCount: 2}},
+				NextCursor: models.EncodeCursor("1"),
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"authors":[{"id":"1","name":"Author1","quote_count":2}],"next_cursor":"` + models.EncodeCursor("1") + `"}` + "\n",
+		},
+		{
+			name:         "invalid cursor",
+			url:          "/authors?cursor=bad",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid cursor\n",
+		},
+		{
+			name:         "repository error",
+			url:          "/authors",
+			expectedPage: models.PageRequest{Limit: models.DefaultPageLimit},
+			mockPage:     (*models.AuthorPage)(nil),
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			if tt.expectedCode != http.StatusBadRequest {
+				mockRepo.On("GetAuthors", mock.Anything, tt.expectedPage).
+					Return(tt.mockPage, tt.mockError)
+			}
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetAuthors(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetAuthorQuotes(t *testing.T) {
+	author := &models.Author{Id: "1", Name: "Author1", QuoteCount: 1}
+	firstPage := models.PageRequest{Limit: models.DefaultPageLimit}
+
+	tests := []struct {
+		name         string
+		mockAuthor   *models.Author
+		authorError  error
+		mockPage     *models.Page
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful get author quotes",
+			mockAuthor:   author,
+			mockPage:     &models.Page{Quotes: []models.Quote{{Id: "3", Author: "Author1", Quote: "Quote3", AuthorId: "1"}}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"quotes":[{"id":"3","author":"Author1","quote":"Quote3","author_id":"1"}]}` + "\n",
+		},
+		{
+			name:         "author not found",
+			mockAuthor:   (*models.Author)(nil),
+			authorError:  repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: "Author not found\n",
+		},
+		{
+			name:         "repository error",
+			mockAuthor:   author,
+			mockPage:     (*models.Page)(nil),
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			mockRepo.On("GetAuthorByID", mock.Anything, "1").
+				Return(tt.mockAuthor, tt.authorError)
+			if tt.authorError == nil {
+				mockRepo.On("GetQuotes", mock.Anything, models.QuoteFilter{AuthorId: "1"}, firstPage).
+					Return(tt.mockPage, tt.mockError)
+			}
+
+			req := httptest.NewRequest("GET", "/authors/1/quotes", nil)
+			req.SetPathValue("id", "1")
+			rr := httptest.NewRecorder()
+
+			handler.GetAuthorQuotes(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockTags     []models.Tag
+		mockError    error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "successful get tags",
+			mockTags:     []models.Tag{{Name: "humor", QuoteCount: 2}, {Name: "stoicism", QuoteCount: 1}},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"tags":[{"name":"humor","quote_count":2},{"name":"stoicism","quote_count":1}]}` + "\n",
+		},
+		{
+			name:         "no tags",
+			mockTags:     []models.Tag{},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"tags":[]}` + "\n",
+		},
+		{
+			name:         "repository error",
+			mockTags:     []models.Tag(nil),
+			mockError:    errors.New("database error"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+			mockRepo.On("GetTags", mock.Anything).Return(tt.mockTags, tt.mockError)
+
+			req := httptest.NewRequest("GET", "/tags", nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetTags(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetDailyQuote(t *testing.T) {
+	now := time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC)
+	today := models.DayNumber(now)
+	quote := &models.Quote{Id: "3", Author: "Author", Quote: "Quote", AuthorId: "1"}
+
+	tests := []struct {
+		name          string
+		query         string
+		mockCount     int
+		mockCountErr  error
+		expectedIndex int
+		mockQuote     *models.Quote
+		mockError     error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:          "successful get daily quote",
+			mockCount:     5,
+			expectedIndex: models.DailyIndex(today, 5),
+			mockQuote:     quote,
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"id":"3","author":"Author","quote":"Quote","author_id":"1"}` + "\n",
+		},
+		{
+			name:          "time zone ahead of UTC",
+			query:         "?tz=Asia/Tokyo",
+			mockCount:     5,
+			expectedIndex: models.DailyIndex(today+1, 5),
+			mockQuote:     quote,
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"id":"3","author":"Author","quote":"Quote","author_id":"1"}` + "\n",
+		},
+		{
+			name:          "invalid tz",
+			query:         "?tz=Mars/Olympus",
+			expectedIndex: -1,
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  "invalid tz\n",
+		},
+		{
+			name:          "no quotes",
+			expectedIndex: -1,
+			expectedCode:  http.StatusNotFound,
+			expectedBody:  "No quotes found\n",
+		},
+		{
+			name:          "count error",
+			mockCountErr:  errors.New("database error"),
+			expectedIndex: -1,
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  "Internal server error\n",
+		},
+		{
+			name:          "quotes deleted meanwhile",
+			mockCount:     1,
+			expectedIndex: 0,
+			mockQuote:     (*models.Quote)(nil),
+			mockError:     repository.ErrNotFound,
+			expectedCode:  http.StatusNotFound,
+			expectedBody:  "No quotes found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			handler := &handlers2.BaseHandler{Repo: mockRepo, Now: func() time.Time { return now }}
+
+			mockRepo.On("CountQuotes", mock.Anything, models.QuoteFilter{}).Return(tt.mockCount, tt.mockCountErr).Maybe()
+			if tt.expectedIndex >= 0 {
+				mockRepo.On("GetQuoteAt", mock.Anything, models.QuoteFilter{}, tt.expectedIndex).Return(tt.mockQuote, tt.mockError)
+			}
+
+			req := httptest.NewRequest("GET", "/quotes/daily"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.GetDailyQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBaseHandler_GetRandomQuote_Rotation(t *testing.T) {
+	quotes := []*models.Quote{
+		{Id: "1", Author: "Test Author", Quote: "First"},
+		{Id: "2", Author: "Test Author", Quote: "Second"},
+	}
+	body := func(q *models.Quote) string {
+		return fmt.Sprintf(`{"id":%q,"author":"Test Author","quote":%q}`+"\n", q.Id, q.Quote)
+	}
+
+	noFilter := models.QuoteFilter{AuthorMatch: models.AuthorExact}
+	rotation := models.Rotation{Seed: 3, Position: 5}
+	next := rotation.Indexes(1, 2)[0]
+
+	tests := []struct {
+		name         string
+		query        string
+		cookie       string
+		setup        func(repo *MockRepository, rs *MockRotations)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "client id parameter",
+			query: "?client_id=kiosk",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				repo.On("CountQuotes", mock.Anything, noFilter).Return(2, nil)
+				rs.On("NextRotation", mock.Anything, "kiosk", 1).Return(rotation, nil)
+				repo.On("GetQuoteAt", mock.Anything, noFilter, next).Return(quotes[next], nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: body(quotes[next]),
+		},
+		{
+			name:   "client id cookie",
+			cookie: "kiosk",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				repo.On("CountQuotes", mock.Anything, noFilter).Return(2, nil)
+				rs.On("NextRotation", mock.Anything, "kiosk", 1).Return(rotation, nil)
+				repo.On("GetQuoteAt", mock.Anything, noFilter, next).Return(quotes[next], nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: body(quotes[next]),
+		},
+		{
+			name:  "filters rotate separately",
+			query: "?client_id=kiosk&language=ru",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				f := models.QuoteFilter{AuthorMatch: models.AuthorExact, Language: "ru"}
+				repo.On("CountQuotes", mock.Anything, f).Return(2, nil)
+				rs.On("NextRotation", mock.Anything, mock.MatchedBy(func(key string) bool {
+					return strings.HasPrefix(key, "kiosk?")
+				}), 1).Return(rotation, nil)
+				repo.On("GetQuoteAt", mock.Anything, f, next).Return(quotes[next], nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: body(quotes[next]),
+		},
+		{
+			name:  "n is capped by the quote count",
+			query: "?client_id=kiosk&n=5",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				repo.On("CountQuotes", mock.Anything, noFilter).Return(2, nil)
+				rs.On("NextRotation", mock.Anything, "kiosk", 2).Return(models.Rotation{Seed: 3}, nil)
+				for _, i := range (models.Rotation{Seed: 3}).Indexes(2, 2) {
+					repo.On("GetQuoteAt", mock.Anything, noFilter, i).Return(quotes[i], nil)
+				}
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:  "no quotes",
+			query: "?client_id=kiosk",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				repo.On("CountQuotes", mock.Anything, noFilter).Return(0, nil)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: "No quotes found\n",
+		},
+		{
+			name:  "rotation store error",
+			query: "?client_id=kiosk",
+			setup: func(repo *MockRepository, rs *MockRotations) {
+				repo.On("CountQuotes", mock.Anything, noFilter).Return(2, nil)
+				rs.On("NextRotation", mock.Anything, "kiosk", 1).Return(models.Rotation{}, errors.New("database error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error\n",
+		},
+		{
+			name:         "client id too long",
+			query:        "?client_id=" + strings.Repeat("k", 65),
+			setup:        func(repo *MockRepository, rs *MockRotations) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "invalid client_id\n",
+		},
+		{
+			name:         "client id with seed",
+			query:        "?client_id=kiosk&seed=card-42",
+			setup:        func(repo *MockRepository, rs *MockRotations) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "client_id can't be combined with seed or weight\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRotations := new(MockRotations)
+			handler := &handlers2.BaseHandler{Repo: mockRepo, Rotations: mockRotations}
+
+			tt.setup(mockRepo, mockRotations)
+
+			req := httptest.NewRequest("GET", "/quotes/random"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "client_id", Value: tt.cookie})
+			}
+			rr := httptest.NewRecorder()
+
+			handler.GetRandomQuote(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			}
+			mockRepo.AssertExpectations(t)
+			mockRotations.AssertExpectations(t)
+		})
+	}
+
+	t.Run("without a rotation store", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		handler := &handlers2.BaseHandler{Repo: mockRepo}
+
+		mockRepo.On("GetRandomQuote", mock.Anything, noFilter, models.RandomRequest{N: 1, Weight: models.WeightUniform}).
+			Return([]models.Quote{*quotes[0]}, nil)
+
+		req := httptest.NewRequest("GET", "/quotes/random?client_id=kiosk", nil)
+		rr := httptest.NewRecorder()
+
+		handler.GetRandomQuote(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, body(quotes[0]), rr.Body.String())
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestBaseHandler_Logging(t *testing.T) {
+	var logs bytes.Buffer
+	mockRepo := new(MockRepository)
+	handler := handlers2.New(mockRepo, nil, logging.New(&logs, logging.FormatJSON, slog.LevelInfo))
+
+	mockRepo.On("GetQuotes", mock.Anything, mock.Anything, mock.Anything).
+		Return((*models.Page)(nil), fmt.Errorf("postgres.GetQuotes: %w", errors.New("db error")))
+
+	req := httptest.NewRequest("GET", "/quotes", nil)
+	req = req.WithContext(logging.WithRequest(req.Context(), &logging.Request{ID: "req-1", Route: "GET /quotes"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetQuotes(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	var rec map[string]any
+	if assert.NoError(t, json.Unmarshal(logs.Bytes(), &rec)) {
+		assert.Equal(t, "ERROR", rec["level"])
+		assert.Equal(t, "Can't get quotes", rec["msg"])
+		assert.Equal(t, "postgres.GetQuotes: db error", rec["error"])
+		assert.Equal(t, "req-1", rec["request_id"])
+		assert.Equal(t, "GET /quotes", rec["route"])
+	}
+}
@@ -4,29 +4,75 @@ import (
 	"context"
 	"github.com/odysseymorphey/quotes-service/internal/models"
 	"github.com/stretchr/testify/mock"
+	"iter"
 )
 
 type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) AddQuote(ctx context.Context, q models.Quote) error {
+func (m *MockRepository) AddQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
 	args := m.Called(ctx, q)
-	return args.Error(0)
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
+
+func (m *MockRepository) AddQuotes(ctx context.Context, quotes []models.Quote) (int, error) {
+	args := m.Called(ctx, quotes)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (*models.Page, error) {
+	args := m.Called(ctx, f, p)
+	return args.Get(0).(*models.Page), args.Error(1)
 }
 
-func (m *MockRepository) GetQuotes(ctx context.Context) ([]models.Quote, error) {
+func (m *MockRepository) AllQuotes(ctx context.Context) iter.Seq2[models.Quote, error] {
 	args := m.Called(ctx)
-	return args.Get(0).([]models.Quote), args.Error(1)
+	return args.Get(0).(iter.Seq2[models.Quote, error])
+}
+
+func (m *MockRepository) GetQuoteByID(ctx context.Context, id string) (*models.Quote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Quote), args.Error(1)
 }
 
-func (m *MockRepository) GetQuotesByAuthor(ctx context.Context, author string) ([]models.Quote, error) {
-	args := m.Called(ctx, author)
+func (m *MockRepository) SearchQuotes(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]models.SearchResult), args.Error(1)
+}
+
+func (m *MockRepository) GetRandomQuote(ctx context.Context, f models.QuoteFilter, r models.RandomRequest) ([]models.Quote, error) {
+	args := m.Called(ctx, f, r)
 	return args.Get(0).([]models.Quote), args.Error(1)
 }
 
-func (m *MockRepository) GetRandomQuote(ctx context.Context) (*models.Quote, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) CountQuotes(ctx context.Context, f models.QuoteFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetQuoteAt(ctx context.Context, f models.QuoteFilter, i int) (*models.Quote, error) {
+	args := m.Called(ctx, f, i)
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
+
+func (m *MockRepository) ViewQuote(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) LikeQuote(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateQuote(ctx context.Context, q models.Quote) (*models.Quote, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
+
+func (m *MockRepository) PatchQuote(ctx context.Context, id string, p models.QuotePatch) (*models.Quote, error) {
+	args := m.Called(ctx, id, p)
 	return args.Get(0).(*models.Quote), args.Error(1)
 }
 
@@ -35,7 +81,31 @@ func (m *MockRepository) DeleteQuote(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockRepository) GetAuthors(ctx context.Context, p models.PageRequest) (*models.AuthorPage, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(*models.AuthorPage), args.Error(1)
+}
+
+func (m *MockRepository) GetAuthorByID(ctx context.Context, id string) (*models.Author, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Author), args.Error(1)
+}
+
+func (m *MockRepository) GetTags(ctx context.Context) ([]models.Tag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Tag), args.Error(1)
+}
+
 func (m *MockRepository) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
+
+type MockRotations struct {
+	mock.Mock
+}
+
+func (m *MockRotations) NextRotation(ctx context.Context, client string, n int) (models.Rotation, error) {
+	args := m.Called(ctx, client, n)
+	return args.Get(0).(models.Rotation), args.Error(1)
+}
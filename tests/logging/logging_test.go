@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := logging.WithRequest(context.Background(), &logging.Request{ID: "req-1", Route: "GET /tags"})
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+
+		l.DebugContext(ctx, "hidden")
+		l.With(slog.String("op", "postgres.GetTags")).InfoContext(ctx, "shown", slog.Int("n", 2))
+
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+		assert.Equal(t, "INFO", rec["level"])
+		assert.Equal(t, "shown", rec["msg"])
+		assert.Equal(t, 2.0, rec["n"])
+		assert.Equal(t, "postgres.GetTags", rec["op"])
+		assert.Equal(t, "req-1", rec["request_id"])
+		assert.Equal(t, "GET /tags", rec["route"])
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := logging.New(&buf, logging.FormatText, slog.LevelDebug)
+
+		l.DebugContext(ctx, "shown")
+
+		assert.Contains(t, buf.String(), `level=DEBUG msg=shown request_id=req-1 route="GET /tags"`)
+	})
+
+	t.Run("NoRequest", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := logging.New(&buf, logging.FormatJSON, slog.LevelInfo)
+
+		l.Info("shown")
+
+		assert.NotContains(t, buf.String(), "request_id")
+		assert.NotContains(t, buf.String(), "route")
+	})
+}
+
+func TestRequestFrom(t *testing.T) {
+	assert.Nil(t, logging.RequestFrom(context.Background()))
+
+	req := &logging.Request{ID: "req-1"}
+	ctx := logging.WithRequest(context.Background(), req)
+	assert.Same(t, req, logging.RequestFrom(ctx))
+}
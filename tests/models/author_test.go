@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Albert Einstein", "albert einstein"},
+		{"  albert   EINSTEIN ", "albert einstein"},
+		{"A. Einstein", "a einstein"},
+		{"Лёв Толстой.", "лев толстой"},
+		{"Jean-Paul Sartre", "jean paul sartre"},
+		{"???", "???"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, models.AuthorKey(tt.name), tt.name)
+	}
+}
+
+func TestNewAuthorPage(t *testing.T) {
+	authors := []models.Author{{Id: "1"}, {Id: "2"}}
+
+	page := models.NewAuthorPage(authors, 1)
+	assert.Equal(t, authors[:1], page.Authors)
+	assert.Equal(t, models.EncodeCursor("1"), page.NextCursor)
+
+	page = models.NewAuthorPage(nil, 1)
+	assert.NotNil(t, page.Authors)
+	assert.Empty(t, page.NextCursor)
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermute(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 10, 17, 100, 1000} {
+		seen := make([]bool, n)
+		for i := range n {
+			p := models.Permute(42, i, n)
+			if assert.True(t, p >= 0 && p < n, "n=%d i=%d", n, i) {
+				assert.False(t, seen[p], "n=%d i=%d", n, i)
+				seen[p] = true
+			}
+			assert.Equal(t, p, models.Permute(42, i, n))
+		}
+	}
+
+	shuffle := func(seed uint64) []int {
+		s := make([]int, 100)
+		for i := range s {
+			s[i] = models.Permute(seed, i, len(s))
+		}
+		return s
+	}
+	assert.NotEqual(t, shuffle(1), shuffle(2))
+}
+
+func TestDailyIndex(t *testing.T) {
+	const n = 7
+
+	for _, round := range []int{-1, 0, 1, 2900} {
+		seen := make(map[int]bool)
+		for day := round * n; day < (round+1)*n; day++ {
+			i := models.DailyIndex(day, n)
+			assert.True(t, i >= 0 && i < n)
+			seen[i] = true
+		}
+		assert.Len(t, seen, n, "round %d", round)
+	}
+}
+
+func TestDailyIndex_NoRepeats(t *testing.T) {
+	for _, n := range []int{2, 3, 5} {
+		for day := -10 * n; day < 200*n; day++ {
+			assert.NotEqual(t, models.DailyIndex(day, n), models.DailyIndex(day+1, n), "n=%d day=%d", n, day)
+		}
+	}
+}
+
+func TestDayNumber(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	now := time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, 0, models.DayNumber(time.Date(1970, 1, 1, 23, 59, 0, 0, time.UTC)))
+	assert.Equal(t, 20743, models.DayNumber(now))
+	assert.Equal(t, 20744, models.DayNumber(now.In(tokyo)))
+}
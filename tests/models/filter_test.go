@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAuthorMatch(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected models.AuthorMatch
+	}{
+		{"", models.AuthorExact},
+		{"exact", models.AuthorExact},
+		{"iexact", models.AuthorIExact},
+		{"prefix", models.AuthorPrefix},
+		{"contains", models.AuthorContains},
+	}
+
+	for _, tt := range tests {
+		m, err := models.ParseAuthorMatch(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.expected, m)
+	}
+
+	for _, in := range []string{"EXACT", "fuzzy"} {
+		_, err := models.ParseAuthorMatch(in)
+		assert.ErrorIs(t, err, models.ErrInvalidAuthorMatch, in)
+	}
+}
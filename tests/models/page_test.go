@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	id, err := models.DecodeCursor(models.EncodeCursor("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	for _, cursor := range []string{"!!!", models.EncodeCursor("abc"), models.EncodeCursor("0"), models.EncodeCursor("-1")} {
+		_, err := models.DecodeCursor(cursor)
+		assert.ErrorIs(t, err, models.ErrInvalidCursor, cursor)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	quotes := []models.Quote{{Id: "1"}, {Id: "2"}, {Id: "3"}}
+
+	page := models.NewPage(quotes, 2)
+	assert.Equal(t, quotes[:2], page.Quotes)
+	assert.Equal(t, models.EncodeCursor("2"), page.NextCursor)
+
+	page = models.NewPage(quotes, 3)
+	assert.Equal(t, quotes, page.Quotes)
+	assert.Empty(t, page.NextCursor)
+
+	page = models.NewPage(nil, 3)
+	assert.NotNil(t, page.Quotes)
+	assert.Empty(t, page.Quotes)
+}
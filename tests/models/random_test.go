@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeededIndexes(t *testing.T) {
+	seed := models.ParseSeed("card-42")
+	assert.Equal(t, seed, models.ParseSeed("card-42"))
+	assert.NotEqual(t, seed, models.ParseSeed("card-43"))
+
+	indexes := models.SeededIndexes(seed, 5, 20)
+	assert.Len(t, indexes, 5)
+	assert.Equal(t, indexes, models.SeededIndexes(seed, 5, 20))
+
+	seen := make(map[int]bool)
+	for _, i := range indexes {
+		assert.True(t, i >= 0 && i < 20)
+		seen[i] = true
+	}
+	assert.Len(t, seen, 5)
+
+	assert.ElementsMatch(t, []int{0, 1, 2}, models.SeededIndexes(seed, 10, 3))
+}
+
+func TestParseWeight(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected models.Weight
+		err      error
+	}{
+		{in: "", expected: models.WeightUniform},
+		{in: "uniform", expected: models.WeightUniform},
+		{in: "popular", expected: models.WeightPopular},
+		{in: "recent", expected: models.WeightRecent},
+		{in: "Popular", err: models.ErrInvalidWeight},
+		{in: "heavy", err: models.ErrInvalidWeight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			w, err := models.ParseWeight(tt.in)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.expected, w)
+		})
+	}
+}
+
+func TestPopularWeight(t *testing.T) {
+	assert.Equal(t, 1.0, models.PopularWeight(0, 0))
+	assert.Equal(t, 4.0, models.PopularWeight(3, 0))
+	assert.Equal(t, 1.0+models.LikeViews, models.PopularWeight(0, 1))
+}
+
+func TestPickIndexed(t *testing.T) {
+	byIndex := map[int]models.Quote{0: {Id: "1"}, 2: {Id: "3"}, 4: {Id: "5"}}
+
+	// Index 3 has no quote, as if it had been deleted.
+	quotes := models.PickIndexed(byIndex, []int{4, 3, 0, 2})
+	assert.Equal(t, []models.Quote{{Id: "5"}, {Id: "1"}, {Id: "3"}}, quotes)
+
+	assert.Empty(t, models.PickIndexed(map[int]models.Quote{}, []int{1}))
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotation(t *testing.T) {
+	const rounds = 6
+
+	for _, count := range []int{1, 2, 3, 5} {
+		all := make([]int, count)
+		for i := range all {
+			all[i] = i
+		}
+
+		for seed := range uint64(200) {
+			var shown []int
+			for pos := range rounds * count {
+				indexes := models.Rotation{Seed: seed, Position: pos}.Indexes(1, count)
+				if assert.Len(t, indexes, 1) {
+					shown = append(shown, indexes[0])
+				}
+			}
+
+			for round := range rounds {
+				assert.ElementsMatch(t, all, shown[round*count:(round+1)*count], "count %d seed %d round %d", count, seed, round)
+			}
+
+			if count == 1 {
+				continue
+			}
+			for i := 1; i < len(shown); i++ {
+				assert.NotEqual(t, shown[i-1], shown[i], "count %d seed %d position %d", count, seed, i)
+			}
+		}
+	}
+}
+
+func TestRotationIndexes(t *testing.T) {
+	r := models.Rotation{Seed: 7, Position: 3}
+
+	indexes := r.Indexes(3, 5)
+	assert.LessOrEqual(t, len(indexes), 3)
+	seen := make(map[int]bool)
+	for _, i := range indexes {
+		assert.False(t, seen[i])
+		seen[i] = true
+	}
+
+	assert.Len(t, models.Rotation{Seed: 7}.Indexes(10, 4), 4)
+	assert.Equal(t, r.Indexes(3, 5), r.Indexes(3, 5))
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{name: "nil", tags: nil, expected: nil},
+		{name: "only empty tags", tags: []string{"", "  "}, expected: nil},
+		{name: "case and spacing", tags: []string{" Stoic  Philosophy ", "HUMOR"}, expected: []string{"humor", "stoic philosophy"}},
+		{name: "sorted without duplicates", tags: []string{"b", "a", "B", "a"}, expected: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, models.NormalizeTags(tt.tags))
+		})
+	}
+}
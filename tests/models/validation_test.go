@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote_Normalize(t *testing.T) {
+	q := models.Quote{Author: "  Albert \t Einstein\n", Quote: "\n Imagination is more important than knowledge.  ", Language: " EN"}
+	q.Normalize()
+
+	assert.Equal(t, "Albert Einstein", q.Author)
+	assert.Equal(t, "Imagination is more important than knowledge.", q.Quote)
+	assert.Equal(t, "en", q.Language)
+}
+
+func TestQuote_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		quote    models.Quote
+		expected []models.FieldError
+	}{
+		{
+			name:  "valid",
+			quote: models.Quote{Author: "Author", Quote: "Quote"},
+		},
+		{
+			name:  "limits count characters, not bytes",
+			quote: models.Quote{Author: strings.Repeat("ж", models.MaxAuthorLength), Quote: strings.Repeat("ж", models.MaxQuoteLength)},
+		},
+		{
+			name:  "missing fields",
+			quote: models.Quote{},
+			expected: []models.FieldError{
+				{Field: "author", Message: "is required"},
+				{Field: "quote", Message: "is required"},
+			},
+		},
+		{
+			name:  "tags",
+			quote: models.Quote{Author: "Author", Quote: "Quote", Tags: []string{"humor", strings.Repeat("ж", models.MaxTagLength)}},
+		},
+		{
+			name:  "language",
+			quote: models.Quote{Author: "Author", Quote: "Quote", Language: "rus"},
+		},
+		{
+			name:  "invalid language",
+			quote: models.Quote{Author: "Author", Quote: "Quote", Language: "russian"},
+			expected: []models.FieldError{
+				{Field: "language", Message: "must be a two or three letter ISO 639 code"},
+			},
+		},
+		{
+			name:  "too many tags",
+			quote: models.Quote{Author: "Author", Quote: "Quote", Tags: strings.Fields("a b c d e f g h i j k")},
+			expected: []models.FieldError{
+				{Field: "tags", Message: "must have at most 10 items"},
+			},
+		},
+		{
+			name:  "too long tag",
+			quote: models.Quote{Author: "Author", Quote: "Quote", Tags: []string{strings.Repeat("a", models.MaxTagLength+1)}},
+			expected: []models.FieldError{
+				{Field: "tags", Message: `"` + strings.Repeat("a", models.MaxTagLength+1) + `" must be at most 30 characters`},
+			},
+		},
+		{
+			name:  "too long",
+			quote: models.Quote{Author: strings.Repeat("a", models.MaxAuthorLength+1), Quote: strings.Repeat("a", models.MaxQuoteLength+1)},
+			expected: []models.FieldError{
+				{Field: "author", Message: "must be at most 30 characters"},
+				{Field: "quote", Message: "must be at most 2000 characters"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quote.Validate()
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *models.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expected, validationErr.Errors)
+		})
+	}
+}
+
+func TestQuotePatch_Validate(t *testing.T) {
+	empty := ""
+	author := " Author "
+
+	p := models.QuotePatch{Author: &author}
+	p.Normalize()
+	assert.Equal(t, "Author", *p.Author)
+	assert.NoError(t, p.Validate())
+
+	p = models.QuotePatch{Quote: &empty}
+	assert.EqualError(t, p.Validate(), "validation failed: quote: is required")
+
+	tags := []string{" Humor", "", "humor "}
+	p = models.QuotePatch{Tags: &tags}
+	p.Normalize()
+	assert.Equal(t, []string{"humor"}, *p.Tags)
+	assert.NoError(t, p.Validate())
+
+	p = models.QuotePatch{}
+	assert.Error(t, p.Validate())
+}
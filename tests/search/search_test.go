@@ -0,0 +1,27 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/search"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"to", "be", "or", "not"}, search.Terms("To be, or NOT to be?"))
+	assert.Equal(t, []string{"ёжик", "42"}, search.Terms("  Ёжик-42 "))
+	assert.Empty(t, search.Terms(`"*" -`))
+}
+
+func TestRank(t *testing.T) {
+	assert.Equal(t, 0.5, search.Rank("let it be, be", []string{"be"}))
+	assert.Equal(t, 0.0, search.Rank("let it be", []string{"be", "not"}))
+	assert.Equal(t, 0.0, search.Rank("let it be", nil))
+	assert.Greater(t, search.Rank("be be be", []string{"be"}), search.Rank("let it be", []string{"be"}))
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "<mark>Let</mark> it &amp; <mark>LET</mark>", search.Highlight("Let it & LET", []string{"let"}))
+	assert.Equal(t, "&lt;b&gt;bold&lt;/b&gt;", search.Highlight("<b>bold</b>", []string{"none"}))
+	assert.Equal(t, "", search.Highlight("", []string{"a"}))
+}
@@ -0,0 +1,381 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/logging"
+	"github.com/odysseymorphey/quotes-service/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) server.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" in")
+				next.ServeHTTP(w, r)
+				calls = append(calls, name+" out")
+			})
+		}
+	}
+
+	h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}), trace("a"), trace("b"), trace("c"))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, []string{"a in", "b in", "c in", "handler", "c out", "b out", "a out"}, calls)
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "Generated"},
+		{name: "FromClient", header: "abc-123", expected: "abc-123"},
+		{name: "TooLong", header: strings.Repeat("a", 129)},
+		{name: "NotPrintable", header: "abc 123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := server.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = server.RequestIDFrom(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(server.RequestIDHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, seen)
+			} else {
+				assert.Len(t, seen, 32)
+			}
+			assert.Equal(t, seen, rr.Header().Get(server.RequestIDHeader))
+		})
+	}
+
+	assert.Empty(t, server.RequestIDFrom(context.Background()))
+}
+
+// records decodes the JSON records logged to buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var recs []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var rec map[string]any
+		require.NoError(t, dec.Decode(&rec))
+		recs = append(recs, rec)
+	}
+
+	return recs
+}
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	l := logging.New(&logs, logging.FormatJSON, slog.LevelInfo)
+
+	t.Run("BeforeResponse", func(t *testing.T) {
+		logs.Reset()
+		h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}), server.RequestID, server.Recover(l))
+
+		req := httptest.NewRequest("GET", "/quotes", nil)
+		req.Header.Set(server.RequestIDHeader, "req-1")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, "Internal server error\n", rr.Body.String())
+		recs := records(t, &logs)
+		require.Len(t, recs, 1)
+		assert.Equal(t, "ERROR", recs[0]["level"])
+		assert.Equal(t, "Panic serving request", recs[0]["msg"])
+		assert.Equal(t, "req-1", recs[0]["request_id"])
+		assert.Equal(t, "/quotes", recs[0]["path"])
+		assert.Equal(t, "boom", recs[0]["panic"])
+		assert.Contains(t, recs[0]["stack"], "runtime/debug.Stack")
+	})
+
+	t.Run("AfterResponse", func(t *testing.T) {
+		h := server.Recover(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			panic("boom")
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		logs.Reset()
+		h := server.Recover(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+		assert.Empty(t, logs.String())
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	l := logging.New(&logs, logging.FormatJSON, slog.LevelInfo)
+
+	h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("panic") != "" {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}), server.RequestID, server.AccessLog(l), server.Recover(l), server.Route("POST /quotes"))
+
+	req := httptest.NewRequest("POST", "/quotes?x=1", nil)
+	req.Header.Set(server.RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	recs := records(t, &logs)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "INFO", recs[0]["level"])
+	assert.Equal(t, "Request served", recs[0]["msg"])
+	assert.Equal(t, "POST", recs[0]["method"])
+	assert.Equal(t, "/quotes?x=1", recs[0]["path"])
+	assert.Equal(t, 201.0, recs[0]["status"])
+	assert.Equal(t, 5.0, recs[0]["bytes"])
+	assert.Contains(t, recs[0], "latency")
+	assert.Equal(t, "req-1", recs[0]["request_id"])
+	// The route is set inside the access log, which still sees it.
+	assert.Equal(t, "POST /quotes", recs[0]["route"])
+
+	// Recovery sits inside the access log, which sees its 500.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/quotes?panic=1", nil))
+
+	recs = records(t, &logs)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "Panic serving request", recs[0]["msg"])
+	assert.Equal(t, "ERROR", recs[1]["level"])
+	assert.Equal(t, 500.0, recs[1]["status"])
+}
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name          string
+		origins       []string
+		method        string
+		origin        string
+		preflight     bool
+		expectedCode  int
+		expectedAllow string
+	}{
+		{
+			name:         "Disabled",
+			origin:       "https://a.example",
+			expectedCode: http.StatusTeapot,
+		},
+		{
+			name:          "Allowed",
+			origins:       []string{"https://a.example"},
+			origin:        "https://a.example",
+			expectedCode:  http.StatusTeapot,
+			expectedAllow: "https://a.example",
+		},
+		{
+			name:         "NotAllowed",
+			origins:      []string{"https://a.example"},
+			origin:       "https://b.example",
+			expectedCode: http.StatusTeapot,
+		},
+		{
+			name:          "Any",
+			origins:       []string{"*"},
+			origin:        "https://b.example",
+			expectedCode:  http.StatusTeapot,
+			expectedAllow: "*",
+		},
+		{
+			name:          "Preflight",
+			origins:       []string{"https://a.example"},
+			method:        "OPTIONS",
+			origin:        "https://a.example",
+			preflight:     true,
+			expectedCode:  http.StatusNoContent,
+			expectedAllow: "https://a.example",
+		},
+		{
+			name:         "PreflightNotAllowed",
+			origins:      []string{"https://a.example"},
+			method:       "OPTIONS",
+			origin:       "https://b.example",
+			preflight:    true,
+			expectedCode: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, "/quotes", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			rr := httptest.NewRecorder()
+
+			server.CORS(tt.origins)(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedAllow, rr.Header().Get("Access-Control-Allow-Origin"))
+			if tt.preflight && tt.expectedAllow != "" {
+				assert.Contains(t, rr.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+				assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), server.RequestIDHeader)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"author":"Author","quote":"Quote"}`+"\n", 100)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		status         int
+		compressed     bool
+	}{
+		{name: "JSON", acceptEncoding: "gzip, deflate", contentType: "application/json", status: 200, compressed: true},
+		{name: "NDJSON", acceptEncoding: "gzip", contentType: "application/x-ndjson", status: 200, compressed: true},
+		{name: "NotAccepted", acceptEncoding: "deflate", contentType: "application/json", status: 200},
+		{name: "Refused", acceptEncoding: "gzip;q=0", contentType: "application/json", status: 200},
+		{name: "NoContentType", acceptEncoding: "gzip", status: 200},
+		{name: "Binary", acceptEncoding: "gzip", contentType: "image/png", status: 200},
+		{name: "NoContent", acceptEncoding: "gzip", contentType: "application/json", status: 204},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := server.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusNoContent {
+					_, _ = io.WriteString(w, body)
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/quotes", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Contains(t, rr.Header().Values("Vary"), "Accept-Encoding")
+
+			if !tt.compressed {
+				assert.Empty(t, rr.Header().Get("Content-Encoding"))
+				if tt.status != http.StatusNoContent {
+					assert.Equal(t, body, rr.Body.String())
+				}
+				return
+			}
+
+			assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+			assert.Less(t, rr.Body.Len(), len(body))
+
+			zr, err := gzip.NewReader(rr.Body)
+			require.NoError(t, err)
+			got, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(got))
+		})
+	}
+}
+
+func TestCompress_Flush(t *testing.T) {
+	flushed := make(chan struct{})
+	h := server.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, "{}\n")
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		<-flushed
+	}))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	defer close(flushed)
+
+	// The first line arrives while the handler is still running.
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.True(t, resp.Uncompressed)
+	line := make([]byte, 3)
+	_, err = io.ReadFull(resp.Body, line)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(line))
+}
+
+func TestTimeout(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	h := server.Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	h = server.Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok = r.Context().Deadline()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ok)
+}
+
+func TestMaxBytes(t *testing.T) {
+	var err error
+	h := server.MaxBytes(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err = io.ReadAll(r.Body)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("12345")))
+
+	var maxBytesErr *http.MaxBytesError
+	assert.ErrorAs(t, err, &maxBytesErr)
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/odysseymorphey/quotes-service/internal/config"
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/server"
+	"github.com/odysseymorphey/quotes-service/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.DiscardHandler)
+
+// slowRepo blocks GetQuotes until release is closed and records whether it
+// was closed while a request was still using it.
+type slowRepo struct {
+	*memory.Storage
+	started  chan struct{}
+	release  chan struct{}
+	inFlight atomic.Int32
+	closed   atomic.Bool
+	early    atomic.Bool
+}
+
+func newSlowRepo() *slowRepo {
+	return &slowRepo{
+		Storage: memory.New(),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (r *slowRepo) GetQuotes(ctx context.Context, f models.QuoteFilter, p models.PageRequest) (*models.Page, error) {
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+
+	close(r.started)
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return r.Storage.GetQuotes(ctx, f, p)
+}
+
+func (r *slowRepo) Close() error {
+	r.early.Store(r.inFlight.Load() > 0)
+	r.closed.Store(true)
+
+	return r.Storage.Close()
+}
+
+// serve starts s and returns the base URL and the result of Serve.
+func serve(t *testing.T, s *server.Server) (string, <-chan error) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Serve(l)
+	}()
+
+	return "http://" + l.Addr().String(), errc
+}
+
+func TestServer_Stop(t *testing.T) {
+	repo := newSlowRepo()
+	s := server.New(config.Default().Server, repo, memory.NewRotations(10), discard)
+	url, errc := serve(t, s)
+
+	resp := make(chan int, 1)
+	go func() {
+		r, err := http.Get(url + "/quotes")
+		if err != nil {
+			resp <- 0
+			return
+		}
+		r.Body.Close()
+		resp <- r.StatusCode
+	}()
+	<-repo.started
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- s.Stop(context.Background())
+	}()
+
+	// The request in flight keeps Stop waiting and the repository open.
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, repo.closed.Load())
+
+	_, err := http.Get(url + "/quotes")
+	assert.Error(t, err, "new connections must be refused")
+
+	close(repo.release)
+	assert.Equal(t, http.StatusOK, <-resp)
+	assert.NoError(t, <-stopped)
+	assert.NoError(t, <-errc)
+	assert.True(t, repo.closed.Load())
+	assert.False(t, repo.early.Load())
+}
+
+func TestServer_Stop_Deadline(t *testing.T) {
+	repo := newSlowRepo()
+	s := server.New(config.Default().Server, repo, memory.NewRotations(10), discard)
+	url, errc := serve(t, s)
+
+	go func() {
+		if r, err := http.Get(url + "/quotes"); err == nil {
+			r.Body.Close()
+		}
+	}()
+	<-repo.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := s.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, <-errc)
+	assert.True(t, repo.closed.Load())
+}
+
+func TestServer_Run(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	cfg := config.Default().Server
+	cfg.Addr = l.Addr().String()
+	s := server.New(cfg, memory.New(), memory.NewRotations(10), discard)
+
+	assert.ErrorContains(t, s.Run(), "failed to listen")
+}
+
+func TestServer_Limits(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.RequestTimeout = 50 * time.Millisecond
+	cfg.MaxBodyBytes = 64
+	cfg.MaxHeaderBytes = 1 << 10
+
+	repo := newSlowRepo()
+	s := server.New(cfg, repo, memory.NewRotations(10), discard)
+	url, _ := serve(t, s)
+	defer s.Stop(context.Background())
+
+	t.Run("RequestTimeout", func(t *testing.T) {
+		resp, err := http.Get(url + "/quotes")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	t.Run("MaxBodyBytes", func(t *testing.T) {
+		body := `{"author":"Author","quote":"` + strings.Repeat("a", 100) + `"}`
+		resp, err := http.Post(url+"/quotes", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		resp, err = http.Post(url+"/quotes", "application/json", strings.NewReader(`{"author":"Author","quote":"Quote"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("MaxHeaderBytes", func(t *testing.T) {
+		req, err := http.NewRequest("GET", url+"/tags", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Padding", strings.Repeat("a", 16<<10))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+	})
+}
+
+func TestServer_Middleware(t *testing.T) {
+	cfg := config.Default().Server
+	cfg.CORSOrigins = []string{"https://a.example"}
+
+	s := server.New(cfg, memory.New(), memory.NewRotations(10), discard)
+	url, _ := serve(t, s)
+	defer s.Stop(context.Background())
+
+	// Preflight requests reach no route, as none accepts OPTIONS.
+	req, err := http.NewRequest("OPTIONS", url+"/quotes", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://a.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "https://a.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.NotEmpty(t, resp.Header.Get(server.RequestIDHeader))
+
+	req, err = http.NewRequest("GET", url+"/tags", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set(server.RequestIDHeader, "req-1")
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "req-1", resp.Header.Get(server.RequestIDHeader))
+}
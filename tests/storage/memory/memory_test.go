@@ -0,0 +1,726 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"github.com/odysseymorphey/quotes-service/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var firstPage = models.PageRequest{Limit: models.DefaultPageLimit}
+
+func TestAddQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	quote, err := s.AddQuote(ctx, models.Quote{Author: "Author1", Quote: "Quote1"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Quote{Id: "1", Author: "Author1", Quote: "Quote1", AuthorId: "1"}, quote)
+
+	quote, err = s.AddQuote(ctx, models.Quote{Id: "42", Author: "Author2", Quote: "Quote2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", quote.Id)
+
+	page, err := s.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Quote{
+		{Id: "1", Author: "Author1", Quote: "Quote1", AuthorId: "1"},
+		{Id: "2", Author: "Author2", Quote: "Quote2", AuthorId: "2"},
+	}, page.Quotes)
+}
+
+func TestAddQuotes(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Quote1"})
+
+	t.Run("Success", func(t *testing.T) {
+		n, err := s.AddQuotes(ctx, []models.Quote{
+			{Author: "Author2", Quote: "Quote2"},
+			{Author: "Author3", Quote: "Quote3"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{
+			{Id: "1", Author: "Author1", Quote: "Quote1", AuthorId: "1"},
+			{Id: "2", Author: "Author2", Quote: "Quote2", AuthorId: "2"},
+			{Id: "3", Author: "Author3", Quote: "Quote3", AuthorId: "3"},
+		}, page.Quotes)
+	})
+}
+
+func TestGetQuotes(t *testing.T) {
+	s := memory.New()
+
+	page, err := s.GetQuotes(context.Background(), models.QuoteFilter{}, firstPage)
+	assert.NoError(t, err)
+	assert.Empty(t, page.Quotes)
+}
+
+func TestGetQuotesByAuthor(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Quote1"})
+	mustAdd(t, s, models.Quote{Author: "Author2", Quote: "Quote2"})
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Quote3"})
+
+	t.Run("Success", func(t *testing.T) {
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{Author: "Author1"}, firstPage)
+		assert.NoError(t, err)
+		assert.Len(t, page.Quotes, 2)
+		for _, q := range page.Quotes {
+			assert.Equal(t, "Author1", q.Author)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{Author: "Unknown"}, firstPage)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Quotes)
+	})
+}
+
+func TestAuthorMatch(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	for _, author := range []string{"Albert Einstein", "einstein", "Einstein", "Альберт Эйнштейн", "100%_real"} {
+		mustAdd(t, s, models.Quote{Author: author, Quote: "Quote"})
+	}
+
+	tests := []struct {
+		name     string
+		filter   models.QuoteFilter
+		expected []string
+	}{
+		{"ExactByDefault", models.QuoteFilter{Author: "Einstein"}, []string{"3"}},
+		{"Exact", models.QuoteFilter{Author: "Einstein", AuthorMatch: models.AuthorExact}, []string{"3"}},
+		{"IExact", models.QuoteFilter{Author: "EINSTEIN", AuthorMatch: models.AuthorIExact}, []string{"2", "3"}},
+		{"Prefix", models.QuoteFilter{Author: "alb", AuthorMatch: models.AuthorPrefix}, []string{"1"}},
+		{"Contains", models.QuoteFilter{Author: "einstein", AuthorMatch: models.AuthorContains}, []string{"1", "2", "3"}},
+		{"NonASCII", models.QuoteFilter{Author: "эйнштейн", AuthorMatch: models.AuthorContains}, []string{"4"}},
+		{"WildcardsAreLiteral", models.QuoteFilter{Author: "%_", AuthorMatch: models.AuthorContains}, []string{"5"}},
+		{"NoWildcardMatch", models.QuoteFilter{Author: "_", AuthorMatch: models.AuthorPrefix}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := s.GetQuotes(ctx, tt.filter, firstPage)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, q := range page.Quotes {
+				ids = append(ids, q.Id)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestAllQuotes(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+	}
+
+	var ids []string
+	for q, err := range s.AllQuotes(ctx) {
+		require.NoError(t, err)
+		ids = append(ids, q.Id)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, err := range s.AllQuotes(canceled) {
+		assert.ErrorIs(t, err, context.Canceled)
+		break
+	}
+}
+
+func TestGetQuoteByID(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		quote, err := s.GetQuoteByID(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, id := range []string{"2", "abc"} {
+			_, err := s.GetQuoteByID(ctx, id)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		}
+	})
+}
+
+func TestSearchQuotes(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Let it be, let it be"})
+	mustAdd(t, s, models.Quote{Author: "Author2", Quote: "To be or not to be"})
+	mustAdd(t, s, models.Quote{Author: "Author3", Quote: "Nothing to see <here>"})
+	mustAdd(t, s, models.Quote{Author: "Author4", Quote: "Быть или не быть"})
+
+	t.Run("Ranked", func(t *testing.T) {
+		results, err := s.SearchQuotes(ctx, "BE", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Greater(t, results[0].Rank, 0.0)
+		assert.GreaterOrEqual(t, results[0].Rank, results[1].Rank)
+	})
+
+	t.Run("AllTermsRequired", func(t *testing.T) {
+		results, err := s.SearchQuotes(ctx, "not be", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "2", results[0].Id)
+		assert.Equal(t, "To <mark>be</mark> or <mark>not</mark> to <mark>be</mark>", results[0].Snippet)
+	})
+
+	t.Run("SnippetIsEscaped", func(t *testing.T) {
+		results, err := s.SearchQuotes(ctx, "here", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Nothing to see &lt;<mark>here</mark>&gt;", results[0].Snippet)
+	})
+
+	t.Run("Unicode", func(t *testing.T) {
+		results, err := s.SearchQuotes(ctx, "быть", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "<mark>Быть</mark> или не <mark>быть</mark>", results[0].Snippet)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		results, err := s.SearchQuotes(ctx, "be", 1)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		for _, q := range []string{"missing", "\"*"} {
+			results, err := s.SearchQuotes(ctx, q, 10)
+			assert.NoError(t, err)
+			assert.NotNil(t, results)
+			assert.Empty(t, results)
+		}
+	})
+}
+
+func TestGetRandomQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+
+		quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1"}}, quotes)
+	})
+
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Короткая", Language: "ru"})
+	mustAdd(t, s, models.Quote{Author: "Other", Quote: "A much longer quote", Language: "en"})
+
+	t.Run("Filters", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			filter   models.QuoteFilter
+			expected string
+		}{
+			{name: "language", filter: models.QuoteFilter{Language: "en"}, expected: "3"},
+			{name: "max length counts characters", filter: models.QuoteFilter{Language: "ru", MaxLength: 8}, expected: "2"},
+			{name: "author", filter: models.QuoteFilter{Author: "Other"}, expected: "3"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				quotes, err := s.GetRandomQuote(ctx, tt.filter, models.RandomRequest{N: 1})
+				require.NoError(t, err)
+				require.Len(t, quotes, 1)
+				assert.Equal(t, tt.expected, quotes[0].Id)
+			})
+		}
+
+		_, err := s.GetRandomQuote(ctx, models.QuoteFilter{MaxLength: 4}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Several", func(t *testing.T) {
+		quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 2})
+		require.NoError(t, err)
+		require.Len(t, quotes, 2)
+		assert.NotEqual(t, quotes[0].Id, quotes[1].Id)
+
+		quotes, err = s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 10})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, q := range quotes {
+			ids = append(ids, q.Id)
+		}
+		assert.ElementsMatch(t, []string{"1", "2", "3"}, ids)
+	})
+
+	t.Run("Seeded", func(t *testing.T) {
+		seed := models.ParseSeed("card")
+		r := models.RandomRequest{N: 2, Seed: &seed}
+
+		// The quotes are 1, 2 and 3, so an index picks the quote with the next id.
+		var expected []string
+		for _, i := range models.SeededIndexes(seed, 2, 3) {
+			expected = append(expected, strconv.Itoa(i+1))
+		}
+
+		for range 3 {
+			quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, r)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, q := range quotes {
+				ids = append(ids, q.Id)
+			}
+			assert.Equal(t, expected, ids)
+		}
+
+		quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{Author: "Other"}, r)
+		require.NoError(t, err)
+		require.Len(t, quotes, 1)
+		assert.Equal(t, "3", quotes[0].Id)
+
+		_, err = s.GetRandomQuote(ctx, models.QuoteFilter{Author: "Nobody"}, r)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestWeightedRandomQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	for i := range 10 {
+		mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote " + strconv.Itoa(i+1)})
+	}
+
+	t.Run("Counters", func(t *testing.T) {
+		assert.NoError(t, s.ViewQuote(ctx, "1"))
+		assert.NoError(t, s.LikeQuote(ctx, "1"))
+		assert.ErrorIs(t, s.ViewQuote(ctx, "42"), repository.ErrNotFound)
+		assert.ErrorIs(t, s.LikeQuote(ctx, "42"), repository.ErrNotFound)
+	})
+
+	// picks counts how often each quote is picked first.
+	picks := func(t *testing.T, w models.Weight) map[string]int {
+		counts := make(map[string]int)
+		for range 500 {
+			quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1, Weight: w})
+			require.NoError(t, err)
+			require.Len(t, quotes, 1)
+			counts[quotes[0].Id]++
+		}
+		return counts
+	}
+
+	t.Run("Popular", func(t *testing.T) {
+		for range 20 {
+			require.NoError(t, s.LikeQuote(ctx, "5"))
+		}
+
+		// Quote 5 weighs 201 against 12 for quote 1 and 1 for the others.
+		counts := picks(t, models.WeightPopular)
+		assert.Greater(t, counts["5"], 350)
+	})
+
+	t.Run("Recent", func(t *testing.T) {
+		counts := picks(t, models.WeightRecent)
+		assert.Greater(t, counts["10"], counts["1"])
+	})
+
+	t.Run("Several", func(t *testing.T) {
+		quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{Author: "Author"}, models.RandomRequest{N: 20, Weight: models.WeightPopular})
+		require.NoError(t, err)
+
+		ids := make(map[string]bool)
+		for _, q := range quotes {
+			ids[q.Id] = true
+		}
+		assert.Len(t, ids, 10)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := s.GetRandomQuote(ctx, models.QuoteFilter{Author: "Nobody"}, models.RandomRequest{N: 1, Weight: models.WeightRecent})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("DeletedQuote", func(t *testing.T) {
+		require.NoError(t, s.DeleteQuote(ctx, "1"))
+		assert.ErrorIs(t, s.LikeQuote(ctx, "1"), repository.ErrNotFound)
+	})
+}
+
+func TestGetQuoteAt(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	t.Run("Empty", func(t *testing.T) {
+		count, err := s.CountQuotes(ctx, models.QuoteFilter{})
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+
+		_, err = s.GetQuoteAt(ctx, models.QuoteFilter{}, 0)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote1", Language: "en"})
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote2"})
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote3", Language: "en"})
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := s.CountQuotes(ctx, models.QuoteFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		count, err = s.CountQuotes(ctx, models.QuoteFilter{Language: "en"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		quote, err := s.GetQuoteAt(ctx, models.QuoteFilter{}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "2", quote.Id)
+
+		quote, err = s.GetQuoteAt(ctx, models.QuoteFilter{Language: "en"}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "3", quote.Id)
+	})
+
+	t.Run("OutOfRange", func(t *testing.T) {
+		_, err := s.GetQuoteAt(ctx, models.QuoteFilter{Language: "en"}, 2)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestUpdateQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		quote, err := s.UpdateQuote(ctx, models.Quote{Id: "1", Author: "New Author", Quote: "New Quote"})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "New Author", Quote: "New Quote", AuthorId: "2"}, quote)
+
+		quote, err = s.GetQuoteByID(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "New Author", quote.Author)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := s.UpdateQuote(ctx, models.Quote{Id: "2", Author: "New Author", Quote: "New Quote"})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestPatchQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		text := "New Quote"
+		quote, err := s.PatchQuote(ctx, "1", models.QuotePatch{Quote: &text})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Author", Quote: "New Quote", AuthorId: "1"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		text := "New Quote"
+		_, err := s.PatchQuote(ctx, "2", models.QuotePatch{Quote: &text})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestDeleteQuote(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Quote1"})
+	mustAdd(t, s, models.Quote{Author: "Author2", Quote: "Quote2"})
+
+	t.Run("Success", func(t *testing.T) {
+		assert.NoError(t, s.DeleteQuote(ctx, "1"))
+
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "2", Author: "Author2", Quote: "Quote2", AuthorId: "2"}}, page.Quotes)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := s.DeleteQuote(ctx, "1")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.Contains(t, err.Error(), "quote not found")
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		err := s.DeleteQuote(ctx, "abc")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.Contains(t, err.Error(), "quote not found")
+	})
+
+	t.Run("IDsAreNotReused", func(t *testing.T) {
+		require.NoError(t, s.DeleteQuote(ctx, "2"))
+		mustAdd(t, s, models.Quote{Author: "Author3", Quote: "Quote3"})
+
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "3", Author: "Author3", Quote: "Quote3", AuthorId: "3"}}, page.Quotes)
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := s.AddQuote(ctx, models.Quote{Author: "Author", Quote: "Quote"})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := s.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1}); err != nil {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+			}
+		}()
+	}
+	wg.Wait()
+
+	page, err := s.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+	assert.NoError(t, err)
+	assert.Len(t, page.Quotes, 50)
+
+	seen := make(map[string]bool)
+	for _, q := range page.Quotes {
+		assert.False(t, seen[q.Id], "duplicate id %s", q.Id)
+		seen[q.Id] = true
+	}
+}
+
+func TestPagination(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		mustAdd(t, s, models.Quote{Author: "Author", Quote: "Quote"})
+	}
+	require.NoError(t, s.DeleteQuote(ctx, "2"))
+
+	var ids []string
+	p := models.PageRequest{Limit: 2}
+	for {
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{}, p)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Quotes), 2)
+
+		for _, q := range page.Quotes {
+			ids = append(ids, q.Id)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+
+		p.After, err = models.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"1", "3", "4", "5"}, ids)
+}
+
+func TestAuthors(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Лев Толстой", Quote: "Quote1"})
+	mustAdd(t, s, models.Quote{Author: "Author2", Quote: "Quote2"})
+	mustAdd(t, s, models.Quote{Author: "лёв толстой.", Quote: "Quote3"})
+	_, err := s.AddQuotes(ctx, []models.Quote{{Author: "ЛЕВ  ТОЛСТОЙ", Quote: "Quote4"}, {Author: "Author3", Quote: "Quote5"}})
+	require.NoError(t, err)
+
+	t.Run("VariantsShareAuthor", func(t *testing.T) {
+		page, err := s.GetAuthors(ctx, firstPage)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{
+			{Id: "1", Name: "Лев Толстой", QuoteCount: 3},
+			{Id: "2", Name: "Author2", QuoteCount: 1},
+			{Id: "3", Name: "Author3", QuoteCount: 1},
+		}, page.Authors)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		page, err := s.GetAuthors(ctx, models.PageRequest{After: 1, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{{Id: "2", Name: "Author2", QuoteCount: 1}}, page.Authors)
+		assert.Equal(t, models.EncodeCursor("2"), page.NextCursor)
+	})
+
+	t.Run("AuthorQuotes", func(t *testing.T) {
+		page, err := s.GetQuotes(ctx, models.QuoteFilter{AuthorId: "1"}, firstPage)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, q := range page.Quotes {
+			ids = append(ids, q.Id)
+		}
+		assert.Equal(t, []string{"1", "3", "4"}, ids)
+	})
+
+	t.Run("GetAuthorByID", func(t *testing.T) {
+		author, err := s.GetAuthorByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, &models.Author{Id: "1", Name: "Лев Толстой", QuoteCount: 3}, author)
+
+		_, err = s.GetAuthorByID(ctx, "42")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("AuthorWithoutQuotesIsHidden", func(t *testing.T) {
+		quote, err := s.UpdateQuote(ctx, models.Quote{Id: "2", Author: "author3", Quote: "Quote2"})
+		require.NoError(t, err)
+		assert.Equal(t, "3", quote.AuthorId)
+
+		_, err = s.GetAuthorByID(ctx, "2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		page, err := s.GetAuthors(ctx, firstPage)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{
+			{Id: "1", Name: "Лев Толстой", QuoteCount: 3},
+			{Id: "3", Name: "Author3", QuoteCount: 2},
+		}, page.Authors)
+	})
+}
+
+func TestTags(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	mustAdd(t, s, models.Quote{Author: "Author1", Quote: "Quote1", Tags: []string{"humor", "stoicism"}})
+	mustAdd(t, s, models.Quote{Author: "Author2", Quote: "Quote2", Tags: []string{"humor"}})
+	_, err := s.AddQuotes(ctx, []models.Quote{
+		{Author: "Author3", Quote: "Quote3", Tags: []string{"stoicism"}},
+		{Author: "Author4", Quote: "Quote4"},
+	})
+	require.NoError(t, err)
+
+	t.Run("Stored", func(t *testing.T) {
+		quote, err := s.GetQuoteByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"humor", "stoicism"}, quote.Tags)
+
+		quote, err = s.GetQuoteByID(ctx, "4")
+		require.NoError(t, err)
+		assert.Nil(t, quote.Tags)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			tags     []string
+			expected []string
+		}{
+			{name: "one tag", tags: []string{"humor"}, expected: []string{"1", "2"}},
+			{name: "all tags must match", tags: []string{"humor", "stoicism"}, expected: []string{"1"}},
+			{name: "unknown tag", tags: []string{"unknown"}, expected: nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				page, err := s.GetQuotes(ctx, models.QuoteFilter{Tags: tt.tags}, firstPage)
+				require.NoError(t, err)
+
+				var ids []string
+				for _, q := range page.Quotes {
+					ids = append(ids, q.Id)
+				}
+				assert.Equal(t, tt.expected, ids)
+			})
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		for range 10 {
+			quotes, err := s.GetRandomQuote(ctx, models.QuoteFilter{Tags: []string{"stoicism"}, Author: "Author3"}, models.RandomRequest{N: 1})
+			require.NoError(t, err)
+			assert.Equal(t, "3", quotes[0].Id)
+		}
+
+		_, err := s.GetRandomQuote(ctx, models.QuoteFilter{Tags: []string{"unknown"}}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		tags, err := s.GetTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Tag{{Name: "humor", QuoteCount: 2}, {Name: "stoicism", QuoteCount: 2}}, tags)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		quote, err := s.UpdateQuote(ctx, models.Quote{Id: "2", Author: "Author2", Quote: "Quote2", Tags: []string{"wisdom"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"wisdom"}, quote.Tags)
+
+		text := "Quote1, edited"
+		quote, err = s.PatchQuote(ctx, "1", models.QuotePatch{Quote: &text})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"humor", "stoicism"}, quote.Tags)
+
+		var none []string
+		quote, err = s.PatchQuote(ctx, "3", models.QuotePatch{Tags: &none})
+		require.NoError(t, err)
+		assert.Nil(t, quote.Tags)
+
+		require.NoError(t, s.DeleteQuote(ctx, "1"))
+
+		tags, err := s.GetTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Tag{{Name: "wisdom", QuoteCount: 1}}, tags)
+	})
+}
+
+func mustAdd(t *testing.T, repo *memory.Storage, q models.Quote) *models.Quote {
+	t.Helper()
+
+	quote, err := repo.AddQuote(context.Background(), q)
+	require.NoError(t, err)
+
+	return quote
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotations(t *testing.T) {
+	rs := memory.NewRotations(2)
+	ctx := context.Background()
+
+	first, err := rs.NextRotation(ctx, "kiosk", 1)
+	assert.NoError(t, err)
+	assert.Zero(t, first.Position)
+
+	t.Run("Advances", func(t *testing.T) {
+		r, err := rs.NextRotation(ctx, "kiosk", 3)
+		assert.NoError(t, err)
+		assert.Equal(t, first.Seed, r.Seed)
+		assert.Equal(t, 1, r.Position)
+
+		r, err = rs.NextRotation(ctx, "kiosk", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, r.Position)
+	})
+
+	t.Run("Separate", func(t *testing.T) {
+		r, err := rs.NextRotation(ctx, "other", 1)
+		assert.NoError(t, err)
+		assert.Zero(t, r.Position)
+	})
+
+	t.Run("LeastRecentlyUsedDropped", func(t *testing.T) {
+		_, err := rs.NextRotation(ctx, "kiosk", 1)
+		assert.NoError(t, err)
+
+		// "other" is the least recently used of the two kept, so it makes
+		// room for "third".
+		_, err = rs.NextRotation(ctx, "third", 1)
+		assert.NoError(t, err)
+
+		r, err := rs.NextRotation(ctx, "kiosk", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, r.Position)
+
+		r, err = rs.NextRotation(ctx, "other", 1)
+		assert.NoError(t, err)
+		assert.Zero(t, r.Position)
+	})
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	postgres2 "github.com/odysseymorphey/quotes-service/pkg/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrateLinksExistingQuotes runs against the database in
+// POSTGRES_TEST_DSN, in a schema of its own that it drops afterwards.
+func TestMigrateLinksExistingQuotes(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	const schema = "migrate_test"
+
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	_, err = admin.Exec(`DROP SCHEMA IF EXISTS ` + schema + ` CASCADE; CREATE SCHEMA ` + schema)
+	require.NoError(t, err)
+	defer admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+
+	// The quotes table of the first version.
+	_, err = admin.Exec(`
+		CREATE TABLE ` + schema + `.quotes (
+			id SERIAL PRIMARY KEY,
+			author VARCHAR(30) NOT NULL,
+			quote TEXT NOT NULL
+		);
+		INSERT INTO ` + schema + `.quotes(author, quote) VALUES ('Author', 'Quote1'), ('Other', 'Quote2'), ('author', 'Quote3');`)
+	require.NoError(t, err)
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema+",public")
+	u.RawQuery = q.Encode()
+
+	db, err := postgres2.New(u.String())
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	page, err := db.GetAuthors(ctx, models.PageRequest{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Author{
+		{Id: "1", Name: "Author", QuoteCount: 2},
+		{Id: "2", Name: "Other", QuoteCount: 1},
+	}, page.Authors)
+
+	quote, err := db.AddQuote(ctx, models.Quote{Author: "AUTHOR", Quote: "Quote4", Tags: []string{"wisdom"}})
+	require.NoError(t, err)
+	assert.Equal(t, "1", quote.AuthorId)
+
+	results, err := db.SearchQuotes(ctx, "Quote2", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// A second start finds nothing left to do.
+	again, err := postgres2.New(u.String())
+	require.NoError(t, err)
+	require.NoError(t, again.Close())
+}
@@ -1,18 +1,33 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/odysseymorphey/quotes-service/internal/logging"
 	postgres2 "github.com/odysseymorphey/quotes-service/pkg/storage/postgres"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
 	"github.com/stretchr/testify/assert"
 )
 
+// quoteColumns are the columns of a selected quote, with tags as a Postgres
+// array literal.
+var quoteColumns = []string{"id", "author", "quote", "author_id", "language", "tags"}
+
+const selectQuotes = "SELECT id, author, quote, author_id, language, ARRAY\\(.+\\) FROM quotes"
+
 func NewMock() (*postgres2.Database, sqlmock.Sqlmock) {
 	db, mock, _ := sqlmock.New()
 	return &postgres2.Database{Db: db}, mock
@@ -28,23 +43,130 @@ func TestAddQuote(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec("INSERT INTO quotes").
-			WithArgs(q.Author, q.Quote).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, q.Author, q.Quote, 1, "", "{}")
+
+		mock.ExpectQuery("INSERT INTO authors\\(name, name_key\\) VALUES \\(\\$1, \\$3\\)\\s+ON CONFLICT \\(name_key\\).+"+
+			"INSERT INTO tags\\(name\\) SELECT unnest\\(\\$4::text\\[\\]\\).+"+
+			"INSERT INTO quotes\\(author, quote, author_id, language\\) SELECT \\$1, \\$2, id, \\$5 FROM a\\s+RETURNING id, author, quote, author_id, language.+"+
+			"INSERT INTO quote_tags\\(quote_id, tag_id\\) SELECT q.id, t.id FROM q, t\\)\\s+"+
+			"SELECT id, author, quote, author_id, language, \\$4::text\\[\\] FROM q").
+			WithArgs(q.Author, q.Quote, "test author", nil, "").
+			WillReturnRows(row)
+
+		quote, err := db.AddQuote(context.Background(), q)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: q.Author, Quote: q.Quote, AuthorId: "1"}, quote)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		tagged := q
+		tagged.Tags = []string{"humor", "stoic philosophy"}
+
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, q.Author, q.Quote, 1, "", `{humor,"stoic philosophy"}`)
 
-		err := db.AddQuote(context.Background(), q)
+		mock.ExpectQuery("INSERT INTO quote_tags").
+			WithArgs(q.Author, q.Quote, "test author", `{"humor","stoic philosophy"}`, "").
+			WillReturnRows(row)
+
+		quote, err := db.AddQuote(context.Background(), tagged)
 		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: q.Author, Quote: q.Quote, AuthorId: "1", Tags: tagged.Tags}, quote)
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		mock.ExpectExec("INSERT INTO quotes").
-			WithArgs(q.Author, q.Quote).
+		mock.ExpectQuery("INSERT INTO quotes").
+			WithArgs(q.Author, q.Quote, "test author", nil, "").
 			WillReturnError(errors.New("connection failed"))
 
-		err := db.AddQuote(context.Background(), q)
+		_, err := db.AddQuote(context.Background(), q)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to execute query")
 	})
+
+	t.Run("TooLong", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO quotes").
+			WithArgs(q.Author, q.Quote, "test author", nil, "").
+			WillReturnError(&pq.Error{Code: "22001"})
+
+		_, err := db.AddQuote(context.Background(), q)
+		assert.ErrorIs(t, err, repository.ErrInvalid)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO quotes").
+			WithArgs(q.Author, q.Quote, "test author", nil, "").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := db.AddQuote(context.Background(), q)
+		assert.ErrorIs(t, err, repository.ErrConflict)
+	})
+}
+
+func TestAddQuotes(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	quotes := []models.Quote{
+		{Author: "Author1", Quote: "Quote1", Tags: []string{"humor", "life"}},
+		{Author: "Author2", Quote: "Quote2"},
+		{Author: "author1", Quote: "Quote3", Tags: []string{"humor"}},
+	}
+
+	expectIDs := func() {
+		mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('quotes', 'id'\)\) FROM generate_series\(1, \$1\)`).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(10).AddRow(11).AddRow(12))
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO authors\(name, name_key\) SELECT \* FROM unnest`).
+			WithArgs(`{"Author1","Author2"}`, `{"author1","author2"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name_key"}).AddRow(7, "author1").AddRow(8, "author2"))
+		expectIDs()
+		copyIn := mock.ExpectPrepare(`COPY "quotes" \("id", "author", "quote", "author_id", "language"\) FROM STDIN`)
+		for i, id := range []int64{7, 8, 7} {
+			copyIn.ExpectExec().WithArgs(int64(10+i), quotes[i].Author, quotes[i].Quote, id, "").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		copyIn.ExpectExec().WithoutArgs().WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO tags\(name\) SELECT DISTINCT unnest\(\$2::text\[\]\).+`+
+			`INSERT INTO quote_tags\(quote_id, tag_id\)\s+SELECT qt.quote_id, t.id FROM unnest\(\$1::int\[\], \$2::text\[\]\)`).
+			WithArgs("{10,10,12}", `{"humor","life","humor"}`).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		n, err := db.AddQuotes(context.Background(), quotes)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO authors`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name_key"}).AddRow(7, "author1").AddRow(8, "author2"))
+		expectIDs()
+		copyIn := mock.ExpectPrepare(`COPY "quotes"`)
+		copyIn.ExpectExec().WithArgs(int64(10), quotes[0].Author, quotes[0].Quote, int64(7), "").WillReturnResult(sqlmock.NewResult(0, 0))
+		copyIn.ExpectExec().WithArgs(int64(11), quotes[1].Author, quotes[1].Quote, int64(8), "").WillReturnError(&pq.Error{Code: "22001"})
+		mock.ExpectRollback()
+
+		_, err := db.AddQuotes(context.Background(), quotes)
+		assert.ErrorIs(t, err, repository.ErrInvalid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AuthorTooLong", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO authors`).WillReturnError(&pq.Error{Code: "22001"})
+		mock.ExpectRollback()
+
+		_, err := db.AddQuotes(context.Background(), quotes)
+		assert.ErrorIs(t, err, repository.ErrInvalid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestGetQuotes(t *testing.T) {
@@ -52,31 +174,50 @@ func TestGetQuotes(t *testing.T) {
 	defer db.Close()
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "author", "quote"}).
-			AddRow(1, "Author1", "Quote1").
-			AddRow(2, "Author2", "Quote2")
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author1", "Quote1", 1, "", "{}").
+			AddRow(2, "Author2", "Quote2", 1, "", "{}")
 
-		mock.ExpectQuery("SELECT \\* FROM quotes").WillReturnRows(rows)
+		mock.ExpectQuery(selectQuotes+" WHERE id > \\$1 ORDER BY id LIMIT \\$2").
+			WithArgs(0, 11).
+			WillReturnRows(rows)
 
-		quotes, err := db.GetQuotes(context.Background())
+		page, err := db.GetQuotes(context.Background(), models.QuoteFilter{}, models.PageRequest{Limit: 10})
 		assert.NoError(t, err)
-		assert.Len(t, quotes, 2)
+		assert.Len(t, page.Quotes, 2)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("NextPage", func(t *testing.T) {
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(3, "Author1", "Quote1", 1, "", "{}").
+			AddRow(4, "Author2", "Quote2", 1, "", "{}")
+
+		mock.ExpectQuery(selectQuotes+" WHERE id > \\$1").
+			WithArgs(2, 2).
+			WillReturnRows(rows)
+
+		page, err := db.GetQuotes(context.Background(), models.QuoteFilter{}, models.PageRequest{After: 2, Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "3", Author: "Author1", Quote: "Quote1", AuthorId: "1"}}, page.Quotes)
+		assert.Equal(t, models.EncodeCursor("3"), page.NextCursor)
 	})
 
 	t.Run("Empty", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "author", "quote"})
-		mock.ExpectQuery("SELECT \\* FROM quotes").WillReturnRows(rows)
+		rows := sqlmock.NewRows(quoteColumns)
+		mock.ExpectQuery(selectQuotes).WillReturnRows(rows)
 
-		quotes, err := db.GetQuotes(context.Background())
+		page, err := db.GetQuotes(context.Background(), models.QuoteFilter{}, models.PageRequest{Limit: 10})
 		assert.NoError(t, err)
-		assert.Empty(t, quotes)
+		assert.Empty(t, page.Quotes)
+		assert.NotNil(t, page.Quotes)
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		mock.ExpectQuery("SELECT \\* FROM quotes").
+		mock.ExpectQuery(selectQuotes).
 			WillReturnError(errors.New("query error"))
 
-		_, err := db.GetQuotes(context.Background())
+		_, err := db.GetQuotes(context.Background(), models.QuoteFilter{}, models.PageRequest{Limit: 10})
 		assert.Error(t, err)
 	})
 }
@@ -88,26 +229,197 @@ func TestGetQuotesByAuthor(t *testing.T) {
 	author := "TestAuthor"
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "author", "quote"}).
-			AddRow(1, author, "Quote1").
-			AddRow(2, author, "Quote2")
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, author, "Quote1", 1, "", "{}").
+			AddRow(2, author, "Quote2", 1, "", "{}")
 
-		mock.ExpectQuery("SELECT \\* FROM quotes WHERE author = ?").
-			WithArgs(author).
+		mock.ExpectQuery(selectQuotes+" WHERE author = \\$1 AND id > \\$2").
+			WithArgs(author, 0, 11).
 			WillReturnRows(rows)
 
-		quotes, err := db.GetQuotesByAuthor(context.Background(), author)
+		page, err := db.GetQuotes(context.Background(), models.QuoteFilter{Author: author}, models.PageRequest{Limit: 10})
 		assert.NoError(t, err)
-		assert.Len(t, quotes, 2)
-		assert.Equal(t, author, quotes[0].Author)
+		assert.Len(t, page.Quotes, 2)
+		assert.Equal(t, author, page.Quotes[0].Author)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
-		mock.ExpectQuery("SELECT \\* FROM quotes WHERE author = ?").
-			WithArgs(author).
+		mock.ExpectQuery(selectQuotes+" WHERE author = \\$1").
+			WithArgs(author, 0, 11).
 			WillReturnError(sql.ErrNoRows)
 
-		_, err := db.GetQuotesByAuthor(context.Background(), author)
+		_, err := db.GetQuotes(context.Background(), models.QuoteFilter{Author: author}, models.PageRequest{Limit: 10})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetQuotesAuthorMatch(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	tests := []struct {
+		name    string
+		filter  models.QuoteFilter
+		query   string
+		pattern string
+	}{
+		{"IExact", models.QuoteFilter{Author: "einstein", AuthorMatch: models.AuthorIExact}, "author ILIKE", "einstein"},
+		{"Prefix", models.QuoteFilter{Author: "Albert", AuthorMatch: models.AuthorPrefix}, "author ILIKE", "Albert%"},
+		{"Contains", models.QuoteFilter{Author: "Einstein", AuthorMatch: models.AuthorContains}, "author ILIKE", "%Einstein%"},
+		{"EscapesWildcards", models.QuoteFilter{Author: `100%_\`, AuthorMatch: models.AuthorContains}, "author ILIKE", `%100\%\_\\%`},
+		{"Exact", models.QuoteFilter{Author: "Einstein", AuthorMatch: models.AuthorExact}, "author =", "Einstein"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := sqlmock.NewRows(quoteColumns).
+				AddRow(1, "Albert Einstein", "Quote1", 1, "", "{}")
+
+			mock.ExpectQuery("^"+selectQuotes+" WHERE "+tt.query+" \\$1 AND id > \\$2 ORDER BY id LIMIT \\$3$").
+				WithArgs(tt.pattern, 0, 11).
+				WillReturnRows(rows)
+
+			page, err := db.GetQuotes(context.Background(), tt.filter, models.PageRequest{Limit: 10})
+			assert.NoError(t, err)
+			assert.Len(t, page.Quotes, 1)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAllQuotes(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author1", "Quote1", 1, "", "{}").
+			AddRow(2, "Author2", "Quote2", 1, "", "{}")
+
+		mock.ExpectQuery(selectQuotes + " ORDER BY id").WillReturnRows(rows)
+
+		var quotes []models.Quote
+		for q, err := range db.AllQuotes(context.Background()) {
+			assert.NoError(t, err)
+			quotes = append(quotes, q)
+		}
+		assert.Len(t, quotes, 2)
+	})
+
+	t.Run("StopEarly", func(t *testing.T) {
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author1", "Quote1", 1, "", "{}").
+			AddRow(2, "Author2", "Quote2", 1, "", "{}")
+
+		mock.ExpectQuery(selectQuotes + " ORDER BY id").
+			WillReturnRows(rows).
+			RowsWillBeClosed()
+
+		for range db.AllQuotes(context.Background()) {
+			break
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RowError", func(t *testing.T) {
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author1", "Quote1", 1, "", "{}").
+			AddRow(2, "Author2", "Quote2", 1, "", "{}").
+			RowError(1, errors.New("connection lost"))
+
+		mock.ExpectQuery(selectQuotes + " ORDER BY id").WillReturnRows(rows)
+
+		var errs []error
+		for _, err := range db.AllQuotes(context.Background()) {
+			errs = append(errs, err)
+		}
+		assert.Len(t, errs, 2)
+		assert.NoError(t, errs[0])
+		assert.ErrorContains(t, errs[1], "connection lost")
+	})
+}
+
+func TestGetQuoteByID(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author", "Quote", 1, "", "{}")
+
+		mock.ExpectQuery(selectQuotes + " WHERE id = \\$1").
+			WithArgs("1").
+			WillReturnRows(row)
+
+		quote, err := db.GetQuoteByID(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(selectQuotes + " WHERE id = \\$1").
+			WithArgs("2").
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+
+		_, err := db.GetQuoteByID(context.Background(), "2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		_, err := db.GetQuoteByID(context.Background(), "abc")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery(selectQuotes + " WHERE id = \\$1").
+			WithArgs("1").
+			WillReturnError(errors.New("db error"))
+
+		_, err := db.GetQuoteByID(context.Background(), "1")
+		assert.Error(t, err)
+	})
+}
+
+func TestSearchQuotes(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "author", "quote", "author_id", "language", "tags", "rank", "snippet"}).
+			AddRow(2, "Author2", "to be or not to be", 1, "", "{}", 0.09, "<mark>to</mark> <mark>be</mark> or not <mark>to</mark> <mark>be</mark>").
+			AddRow(1, "Author1", "let it be", 1, "", "{}", 0.06, "let it <mark>be</mark>")
+
+		mock.ExpectQuery("FROM quotes, websearch_to_tsquery\\('simple', \\$1\\) AS query\\s+WHERE search @@ query\\s+ORDER BY rank DESC, id\\s+LIMIT \\$2").
+			WithArgs("to be", 10).
+			WillReturnRows(rows)
+
+		results, err := db.SearchQuotes(context.Background(), "to be", 10)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, "2", results[0].Id)
+		assert.Equal(t, "to be or not to be", results[0].Quote.Quote)
+		assert.Equal(t, 0.09, results[0].Rank)
+		assert.Equal(t, "let it <mark>be</mark>", results[1].Snippet)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery("websearch_to_tsquery").
+			WithArgs("nothing", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "author", "quote", "author_id", "language", "tags", "rank", "snippet"}))
+
+		results, err := db.SearchQuotes(context.Background(), "nothing", 10)
+		assert.NoError(t, err)
+		assert.NotNil(t, results)
+		assert.Empty(t, results)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("websearch_to_tsquery").
+			WithArgs("to be", 10).
+			WillReturnError(errors.New("db error"))
+
+		_, err := db.SearchQuotes(context.Background(), "to be", 10)
 		assert.Error(t, err)
 	})
 }
@@ -117,23 +429,425 @@ func TestGetRandomQuote(t *testing.T) {
 	defer db.Close()
 
 	t.Run("Success", func(t *testing.T) {
-		row := sqlmock.NewRows([]string{"id", "author", "quote"}).
-			AddRow(1, "Author", "Quote")
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(2, "Author", "Quote2", 1, "", "{}").
+			AddRow(1, "Author", "Quote1", 1, "", "{}")
+
+		mock.ExpectQuery("^WITH b AS \\(SELECT min\\(id\\) AS lo, max\\(id\\) AS hi FROM quotes\\),.+generate_series\\(1, \\$1\\).+" +
+			"SELECT q.\\* FROM p CROSS JOIN LATERAL \\(SELECT id, author, quote, author_id, language, ARRAY\\(.+\\) FROM quotes WHERE id = p.id\\) q\\s+" +
+			"ORDER BY random\\(\\)$").
+			WithArgs(8).
+			WillReturnRows(rows)
+
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 2})
+		assert.NoError(t, err)
+		assert.Len(t, quotes, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Gaps", func(t *testing.T) {
+		// Ids in gaps hit nothing and are sampled again, only for the
+		// quotes still missing. Quotes hit twice count once.
+		mock.ExpectQuery("generate_series").
+			WithArgs(12).
+			WillReturnRows(sqlmock.NewRows(quoteColumns).
+				AddRow(4, "Author", "Quote4", 1, "", "{}"))
+		mock.ExpectQuery("generate_series").
+			WithArgs(8).
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+		mock.ExpectQuery("generate_series").
+			WithArgs(8).
+			WillReturnRows(sqlmock.NewRows(quoteColumns).
+				AddRow(4, "Author", "Quote4", 1, "", "{}").
+				AddRow(7, "Author", "Quote7", 1, "", "{}").
+				AddRow(9, "Author", "Quote9", 1, "", "{}"))
+
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4", "7", "9"}, []string{quotes[0].Id, quotes[1].Id, quotes[2].Id})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		// With fewer quotes than asked for, every round comes back short
+		// and the quotes are picked by index.
+		for range 3 {
+			mock.ExpectQuery("generate_series").
+				WillReturnRows(sqlmock.NewRows(quoteColumns).
+					AddRow(1, "Author", "Quote1", 1, "", "{}"))
+		}
+
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("row_number\\(\\) OVER \\(ORDER BY id\\) - 1 AS i FROM quotes\\s+\\) m").
+			WithArgs("{0}").
+			WillReturnRows(sqlmock.NewRows(append(quoteColumns, "i")).
+				AddRow(1, "Author", "Quote1", 1, "", "{}", 0))
+
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 3})
+		assert.NoError(t, err)
+		assert.Len(t, quotes, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		// Filtered picks take random indexes among the matching quotes.
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes WHERE author ILIKE \\$1 AND language = \\$2 AND char_length\\(quote\\) <= \\$3$").
+			WithArgs("auth%", "en", 140).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("FROM quotes WHERE author ILIKE \\$1 AND language = \\$2 AND char_length\\(quote\\) <= \\$3\\s+"+
+			"\\) m JOIN quotes ON quotes.id = m.quote_id\\s+WHERE m.i = ANY\\(\\$4\\)$").
+			WithArgs("auth%", "en", 140, "{0}").
+			WillReturnRows(sqlmock.NewRows(append(quoteColumns, "i")).
+				AddRow(1, "Author", "Quote", 1, "en", "{}", 0))
+
+		f := models.QuoteFilter{Author: "auth", AuthorMatch: models.AuthorPrefix, Language: "en", MaxLength: 140}
+		quotes, err := db.GetRandomQuote(context.Background(), f, models.RandomRequest{N: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1", Language: "en"}}, quotes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes WHERE id IN \\(.+WHERE t.name = ANY\\(\\$1\\) GROUP BY qt.quote_id HAVING count\\(\\*\\) = \\$2\\s+\\)$").
+			WithArgs("{\"humor\"}", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("m.i = ANY\\(\\$3\\)$").
+			WithArgs("{\"humor\"}", 1, "{0}").
+			WillReturnRows(sqlmock.NewRows(append(quoteColumns, "i")).
+				AddRow(1, "Author", "Quote", 1, "", "{humor,life}", 0))
+
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{Tags: []string{"humor"}}, models.RandomRequest{N: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1", Tags: []string{"humor", "life"}}}, quotes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for range 3 {
+			mock.ExpectQuery("generate_series").
+				WithArgs(4).
+				WillReturnRows(sqlmock.NewRows(quoteColumns))
+		}
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes$").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		_, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Seeded", func(t *testing.T) {
+		seed := models.ParseSeed("card")
+		indexes := models.SeededIndexes(seed, 2, 3)
+
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes WHERE language = \\$1$").
+			WithArgs("en").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		// Rows come back in id order and are put in the order of the indexes.
+		rows := sqlmock.NewRows(append(quoteColumns, "i"))
+		for i := range 3 {
+			rows.AddRow(i+1, "Author", "Quote", 1, "en", "{}", i)
+		}
+
+		mock.ExpectQuery("^SELECT id, author, quote, author_id, language, ARRAY\\(.+\\), m.i FROM \\(\\s+"+
+			"SELECT id AS quote_id, row_number\\(\\) OVER \\(ORDER BY id\\) - 1 AS i FROM quotes WHERE language = \\$1\\s+"+
+			"\\) m JOIN quotes ON quotes.id = m.quote_id\\s+WHERE m.i = ANY\\(\\$2\\)$").
+			WithArgs("en", fmt.Sprintf("{%d,%d}", indexes[0], indexes[1])).
+			WillReturnRows(rows)
+
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{Language: "en"}, models.RandomRequest{N: 2, Seed: &seed})
+		assert.NoError(t, err)
+		if assert.Len(t, quotes, 2) {
+			assert.Equal(t, strconv.Itoa(indexes[0]+1), quotes[0].Id)
+			assert.Equal(t, strconv.Itoa(indexes[1]+1), quotes[1].Id)
+		}
+	})
+
+	t.Run("Popular", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author", "Quote", 1, "", "{}")
+
+		mock.ExpectQuery("^"+selectQuotes+" LEFT JOIN quote_counters c ON c.quote_id = quotes.id WHERE language = \\$1"+
+			" ORDER BY -ln\\(1 - random\\(\\)\\) / \\(1 \\+ COALESCE\\(c.views, 0\\) \\+ 10 \\* COALESCE\\(c.likes, 0\\)\\) LIMIT \\$2$").
+			WithArgs("en", 1).
+			WillReturnRows(row)
+
+		r := models.RandomRequest{N: 1, Weight: models.WeightPopular}
+		quotes, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{Language: "en"}, r)
+		assert.NoError(t, err)
+		assert.Len(t, quotes, 1)
+	})
+
+	t.Run("Recent", func(t *testing.T) {
+		mock.ExpectQuery("^" + selectQuotes + " ORDER BY -ln\\(1 - random\\(\\)\\) / quotes.id LIMIT \\$1$").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+
+		_, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 3, Weight: models.WeightRecent})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("generate_series").WillReturnError(errors.New("db error"))
+
+		_, err := db.GetRandomQuote(context.Background(), models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCountQuotes(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("^SELECT count\\(\\*\\) FROM quotes WHERE language = \\$1$").
+			WithArgs("en").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := db.CountQuotes(context.Background(), models.QuoteFilter{Language: "en"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count").WillReturnError(errors.New("db error"))
+
+		_, err := db.CountQuotes(context.Background(), models.QuoteFilter{})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetQuoteAt(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(3, "Author", "Quote", 1, "", "{}")
 
-		mock.ExpectQuery("^SELECT \\* FROM quotes ORDER BY random\\(\\) LIMIT 1$").
+		mock.ExpectQuery("^" + selectQuotes + " ORDER BY id OFFSET \\$1 LIMIT 1$").
+			WithArgs(2).
 			WillReturnRows(row)
 
-		quote, err := db.GetRandomQuote(context.Background())
+		quote, err := db.GetQuoteAt(context.Background(), models.QuoteFilter{}, 2)
 		assert.NoError(t, err)
-		assert.NotNil(t, quote)
+		assert.Equal(t, &models.Quote{Id: "3", Author: "Author", Quote: "Quote", AuthorId: "1"}, quote)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
-		mock.ExpectQuery("SELECT * FROM quotes ORDER BY random() LIMIT 1").
-			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("^"+selectQuotes+" WHERE language = \\$1 ORDER BY id OFFSET \\$2 LIMIT 1$").
+			WithArgs("en", 5).
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+
+		_, err := db.GetQuoteAt(context.Background(), models.QuoteFilter{Language: "en"}, 5)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestCounters(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("View", func(t *testing.T) {
+		mock.ExpectExec("^INSERT INTO quote_counters\\(quote_id, views\\) SELECT id, 1 FROM quotes WHERE id = \\$1\\s+" +
+			"ON CONFLICT \\(quote_id\\) DO UPDATE SET views = quote_counters.views \\+ 1$").
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, db.ViewQuote(context.Background(), "1"))
+	})
+
+	t.Run("Like", func(t *testing.T) {
+		mock.ExpectExec("SET likes = quote_counters.likes \\+ 1$").
+			WithArgs("1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, db.LikeQuote(context.Background(), "1"))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO quote_counters").
+			WithArgs("2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, db.LikeQuote(context.Background(), "2"), repository.ErrNotFound)
+		assert.ErrorIs(t, db.ViewQuote(context.Background(), "abc"), repository.ErrNotFound)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO quote_counters").WillReturnError(errors.New("db error"))
 
-		_, err := db.GetRandomQuote(context.Background())
+		err := db.ViewQuote(context.Background(), "1")
 		assert.Error(t, err)
+		assert.NotErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestNextRotation(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("^INSERT INTO client_rotations\\(client_id, seed, position\\) VALUES \\(\\$1, \\$2, \\$3\\)\\s+"+
+			"ON CONFLICT \\(client_id\\) DO UPDATE SET position = client_rotations.position \\+ \\$3, updated_at = now\\(\\)\\s+"+
+			"RETURNING seed, position - \\$3$").
+			WithArgs("kiosk", sqlmock.AnyArg(), 2).
+			WillReturnRows(sqlmock.NewRows([]string{"seed", "position"}).AddRow(-1, 4))
+
+		r, err := db.NextRotation(context.Background(), "kiosk", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, models.Rotation{Seed: 1<<64 - 1, Position: 4}, r)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO client_rotations").WillReturnError(errors.New("db error"))
+
+		_, err := db.NextRotation(context.Background(), "kiosk", 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestNextRotation_Prune(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+	db.RotationTTL = 24 * time.Hour
+
+	expectRotation := func() {
+		mock.ExpectQuery("INSERT INTO client_rotations").
+			WillReturnRows(sqlmock.NewRows([]string{"seed", "position"}).AddRow(1, 0))
+	}
+
+	// Stale rotations are deleted after every hundredth one, a batch at a
+	// time, and failing to do so doesn't fail the rotation.
+	for range 2 {
+		for range 100 {
+			expectRotation()
+		}
+		mock.ExpectExec("^DELETE FROM client_rotations WHERE client_id IN \\(\\s+"+
+			"SELECT client_id FROM client_rotations WHERE updated_at < now\\(\\) - \\$1 \\* interval '1 second'\\s+"+
+			"ORDER BY updated_at LIMIT \\$2\\s+\\)$").
+			WithArgs(86400.0, 1000).
+			WillReturnError(errors.New("db error"))
+	}
+
+	for range 200 {
+		_, err := db.NextRotation(context.Background(), fmt.Sprintf("client-%d", rand.Int()), 1)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Without a TTL nothing is deleted.
+	db.RotationTTL = 0
+	for range 100 {
+		expectRotation()
+	}
+	for range 100 {
+		_, err := db.NextRotation(context.Background(), "kiosk", 1)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateQuote(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	q := models.Quote{Id: "1", Author: "New Author", Quote: "New Quote"}
+
+	t.Run("Success", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, q.Author, q.Quote, 2, "", "{}")
+
+		mock.ExpectQuery("INSERT INTO authors\\(name, name_key\\) VALUES \\(\\$1, \\$3\\).+"+
+			"UPDATE quotes SET author = \\$1, quote = \\$2, author_id = a.id, language = \\$6 FROM a WHERE quotes.id = \\$5\\s+"+
+			"RETURNING quotes.id, author, quote, author_id, language.+"+
+			"DELETE FROM quote_tags WHERE \\$4::text\\[\\] IS NOT NULL.+"+
+			"INSERT INTO quote_tags\\(quote_id, tag_id\\) SELECT q.id, t.id FROM q, t\\s+ON CONFLICT DO NOTHING").
+			WithArgs(q.Author, q.Quote, "new author", "{}", q.Id, "").
+			WillReturnRows(row)
+
+		quote, err := db.UpdateQuote(context.Background(), q)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: q.Author, Quote: q.Quote, AuthorId: "2"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE quotes").
+			WithArgs(q.Author, q.Quote, "new author", "{}", q.Id, "").
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+
+		_, err := db.UpdateQuote(context.Background(), q)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE quotes").
+			WithArgs(q.Author, q.Quote, "new author", "{}", q.Id, "").
+			WillReturnError(errors.New("db error"))
+
+		_, err := db.UpdateQuote(context.Background(), q)
+		assert.Error(t, err)
+	})
+}
+
+func TestPatchQuote(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	author := "New Author"
+	text := "New Quote"
+
+	t.Run("Success", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, author, "Old Quote", 2, "", "{}")
+
+		mock.ExpectQuery("INSERT INTO authors\\(name, name_key\\) SELECT \\$1::varchar, \\$3::text WHERE \\$1 IS NOT NULL.+"+
+			"UPDATE quotes SET author = COALESCE\\(\\$1, author\\), quote = COALESCE\\(\\$2, quote\\),\\s+"+
+			"author_id = COALESCE\\(\\(SELECT id FROM a\\), author_id\\), language = COALESCE\\(\\$6, language\\)\\s+WHERE id = \\$5.+"+
+			"SELECT id, author, quote, author_id, language, COALESCE\\(\\$4::text\\[\\], ARRAY\\(").
+			WithArgs(author, nil, "new author", nil, "1", nil).
+			WillReturnRows(row)
+
+		quote, err := db.PatchQuote(context.Background(), "1", models.QuotePatch{Author: &author})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: author, Quote: "Old Quote", AuthorId: "2"}, quote)
+	})
+
+	t.Run("QuoteOnly", func(t *testing.T) {
+		row := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Old Author", text, 1, "", "{}")
+
+		mock.ExpectQuery("UPDATE quotes").
+			WithArgs(nil, text, nil, nil, "1", nil).
+			WillReturnRows(row)
+
+		quote, err := db.PatchQuote(context.Background(), "1", models.QuotePatch{Quote: &text})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Old Author", Quote: text, AuthorId: "1"}, quote)
+	})
+
+	t.Run("RemoveTags", func(t *testing.T) {
+		var none []string
+
+		mock.ExpectQuery("UPDATE quotes").
+			WithArgs(nil, nil, nil, "{}", "1", nil).
+			WillReturnRows(sqlmock.NewRows(quoteColumns).AddRow(1, "Old Author", "Old Quote", 1, "", "{}"))
+
+		quote, err := db.PatchQuote(context.Background(), "1", models.QuotePatch{Tags: &none})
+		assert.NoError(t, err)
+		assert.Nil(t, quote.Tags)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE quotes").
+			WithArgs(author, nil, "new author", nil, "2", nil).
+			WillReturnRows(sqlmock.NewRows(quoteColumns))
+
+		_, err := db.PatchQuote(context.Background(), "2", models.QuotePatch{Author: &author})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
 	})
 }
 
@@ -158,7 +872,7 @@ func TestDeleteQuote(t *testing.T) {
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := db.DeleteQuote(context.Background(), id)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
 		assert.Contains(t, err.Error(), "quote not found")
 	})
 
@@ -172,6 +886,125 @@ func TestDeleteQuote(t *testing.T) {
 	})
 }
 
+func TestGetAuthors(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "count"}).
+			AddRow(1, "Author1", 3).
+			AddRow(2, "Author2", 1)
+
+		mock.ExpectQuery("SELECT a.id, a.name, count\\(\\*\\) FROM authors a JOIN quotes q ON q.author_id = a.id\\s+"+
+			"WHERE a.id > \\$1 GROUP BY a.id ORDER BY a.id LIMIT \\$2").
+			WithArgs(0, 2).
+			WillReturnRows(rows)
+
+		page, err := db.GetAuthors(context.Background(), models.PageRequest{Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Author{{Id: "1", Name: "Author1", QuoteCount: 3}}, page.Authors)
+		assert.Equal(t, models.EncodeCursor("1"), page.NextCursor)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery("FROM authors").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "count"}))
+
+		page, err := db.GetAuthors(context.Background(), models.PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		assert.NotNil(t, page.Authors)
+		assert.Empty(t, page.Authors)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("FROM authors").WillReturnError(errors.New("query error"))
+
+		_, err := db.GetAuthors(context.Background(), models.PageRequest{Limit: 10})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetAuthorByID(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		row := sqlmock.NewRows([]string{"id", "name", "count"}).AddRow(1, "Author1", 3)
+
+		mock.ExpectQuery("FROM authors a JOIN quotes q ON q.author_id = a.id\\s+WHERE a.id = \\$1 GROUP BY a.id").
+			WithArgs("1").
+			WillReturnRows(row)
+
+		author, err := db.GetAuthorByID(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Author{Id: "1", Name: "Author1", QuoteCount: 3}, author)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery("FROM authors").
+			WithArgs("2").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "count"}))
+
+		_, err := db.GetAuthorByID(context.Background(), "2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		_, err := db.GetAuthorByID(context.Background(), "abc")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Quotes", func(t *testing.T) {
+		rows := sqlmock.NewRows(quoteColumns).
+			AddRow(1, "Author1", "Quote1", 1, "", "{}")
+
+		mock.ExpectQuery("^"+selectQuotes+" WHERE author_id = \\$1 AND id > \\$2 ORDER BY id LIMIT \\$3$").
+			WithArgs("1", 0, 11).
+			WillReturnRows(rows)
+
+		page, err := db.GetQuotes(context.Background(), models.QuoteFilter{AuthorId: "1"}, models.PageRequest{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, page.Quotes, 1)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTags(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"name", "count"}).
+			AddRow("humor", 3).
+			AddRow("life", 1)
+
+		mock.ExpectQuery("SELECT t.name, count\\(\\*\\) FROM tags t JOIN quote_tags qt ON qt.tag_id = t.id\\s+" +
+			"GROUP BY t.id ORDER BY count\\(\\*\\) DESC, t.name").
+			WillReturnRows(rows)
+
+		tags, err := db.GetTags(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Tag{{Name: "humor", QuoteCount: 3}, {Name: "life", QuoteCount: 1}}, tags)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		mock.ExpectQuery("FROM tags").WillReturnRows(sqlmock.NewRows([]string{"name", "count"}))
+
+		tags, err := db.GetTags(context.Background())
+		assert.NoError(t, err)
+		assert.NotNil(t, tags)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mock.ExpectQuery("FROM tags").WillReturnError(errors.New("query error"))
+
+		_, err := db.GetTags(context.Background())
+		assert.Error(t, err)
+	})
+}
+
 func TestClose(t *testing.T) {
 	db, mock := NewMock()
 
@@ -187,8 +1020,93 @@ func TestContextHandling(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
 	defer cancel()
 
-	mock.ExpectExec("INSERT INTO quotes").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO quotes").WillReturnRows(sqlmock.NewRows(quoteColumns).AddRow(1, "", "", 1, "", "{}"))
 	<-ctx.Done()
-	err := db.AddQuote(ctx, models.Quote{})
+	_, err := db.AddQuote(ctx, models.Quote{})
 	assert.Error(t, err)
 }
+
+func TestLogging(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	var logs bytes.Buffer
+	db.Log = logging.New(&logs, logging.FormatJSON, slog.LevelDebug)
+	ctx := logging.WithRequest(context.Background(), &logging.Request{ID: "req-1", Route: "GET /quotes/{id}"})
+
+	tests := []struct {
+		name          string
+		rows          *sqlmock.Rows
+		err           error
+		expectedErr   error
+		expectedLevel string
+		expectedMsg   string
+	}{
+		{
+			name:          "Success",
+			rows:          sqlmock.NewRows(quoteColumns).AddRow(1, "Author", "Quote", 1, "", "{}"),
+			expectedLevel: "DEBUG",
+			expectedMsg:   "Query done",
+		},
+		{
+			name:          "NotFound",
+			rows:          sqlmock.NewRows(quoteColumns),
+			expectedErr:   repository.ErrNotFound,
+			expectedLevel: "DEBUG",
+			expectedMsg:   "Query failed",
+		},
+		{
+			name:          "Canceled",
+			err:           &pq.Error{Code: "57014"},
+			expectedErr:   repository.ErrCanceled,
+			expectedLevel: "WARN",
+			expectedMsg:   "Query canceled",
+		},
+		{
+			name:          "Error",
+			err:           errors.New("db error"),
+			expectedLevel: "ERROR",
+			expectedMsg:   "Query failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			q := mock.ExpectQuery(selectQuotes + " WHERE id = \\$1").WithArgs("1")
+			if tt.err != nil {
+				q.WillReturnError(tt.err)
+			} else {
+				q.WillReturnRows(tt.rows)
+			}
+
+			_, err := db.GetQuoteByID(ctx, "1")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+
+			var rec map[string]any
+			if assert.NoError(t, json.Unmarshal(logs.Bytes(), &rec)) {
+				assert.Equal(t, tt.expectedLevel, rec["level"])
+				assert.Equal(t, tt.expectedMsg, rec["msg"])
+				assert.Equal(t, "postgres.GetQuoteByID", rec["op"])
+				assert.Contains(t, rec, "latency")
+				assert.Equal(t, "req-1", rec["request_id"])
+				assert.Equal(t, "GET /quotes/{id}", rec["route"])
+				assert.Equal(t, err != nil, rec["error"] != nil)
+			}
+		})
+	}
+
+	// Successes aren't even formatted above debug level.
+	logs.Reset()
+	db.Log = logging.New(&logs, logging.FormatJSON, slog.LevelInfo)
+	mock.ExpectQuery(selectQuotes + " WHERE id = \\$1").
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows(quoteColumns).AddRow(1, "Author", "Quote", 1, "", "{}"))
+
+	_, err := db.GetQuoteByID(ctx, "1")
+	assert.NoError(t, err)
+	assert.Empty(t, logs.String())
+}
@@ -0,0 +1,777 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/odysseymorphey/quotes-service/internal/models"
+	"github.com/odysseymorphey/quotes-service/internal/repository"
+	"github.com/odysseymorphey/quotes-service/pkg/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var firstPage = models.PageRequest{Limit: models.DefaultPageLimit}
+
+func NewTestDB(t *testing.T) *sqlite.Database {
+	t.Helper()
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "quotes.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+
+	db, err := sqlite.New(path)
+	require.NoError(t, err)
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+	require.NoError(t, db.Close())
+
+	db, err = sqlite.New(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	page, err := db.GetQuotes(context.Background(), models.QuoteFilter{}, firstPage)
+	assert.NoError(t, err)
+	assert.Len(t, page.Quotes, 1)
+}
+
+func TestAddQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		quote, err := db.AddQuote(ctx, models.Quote{Author: "Test Author", Quote: "Test Quote"})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Test Author", Quote: "Test Quote", AuthorId: "1"}, quote)
+
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "1", Author: "Test Author", Quote: "Test Quote", AuthorId: "1"}}, page.Quotes)
+	})
+
+	t.Run("AuthorTooLong", func(t *testing.T) {
+		_, err := db.AddQuote(ctx, models.Quote{Author: "An author name that is way too long", Quote: "Quote"})
+		assert.ErrorIs(t, err, repository.ErrInvalid)
+		assert.Contains(t, err.Error(), "failed to add author")
+	})
+}
+
+func TestAddQuotes(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Quote1"})
+
+	t.Run("Success", func(t *testing.T) {
+		n, err := db.AddQuotes(ctx, []models.Quote{
+			{Author: "Author2", Quote: "Quote2"},
+			{Author: "Author3", Quote: "Quote3"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{
+			{Id: "1", Author: "Author1", Quote: "Quote1", AuthorId: "1"},
+			{Id: "2", Author: "Author2", Quote: "Quote2", AuthorId: "2"},
+			{Id: "3", Author: "Author3", Quote: "Quote3", AuthorId: "3"},
+		}, page.Quotes)
+	})
+
+	t.Run("Atomic", func(t *testing.T) {
+		_, err := db.AddQuotes(ctx, []models.Quote{
+			{Author: "Author4", Quote: "Quote4"},
+			{Author: "An author name that is way too long", Quote: "Quote5"},
+		})
+		assert.ErrorIs(t, err, repository.ErrInvalid)
+
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Len(t, page.Quotes, 3)
+	})
+}
+
+func TestGetQuotes(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	t.Run("Empty", func(t *testing.T) {
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Quotes)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Quote1"})
+		mustAdd(t, db, models.Quote{Author: "Author2", Quote: "Quote2"})
+
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{}, firstPage)
+		assert.NoError(t, err)
+		assert.Len(t, page.Quotes, 2)
+	})
+}
+
+func TestGetQuotesByAuthor(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Quote1"})
+	mustAdd(t, db, models.Quote{Author: "Author2", Quote: "Quote2"})
+	mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Quote3"})
+
+	t.Run("Success", func(t *testing.T) {
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{Author: "Author1"}, firstPage)
+		assert.NoError(t, err)
+		assert.Len(t, page.Quotes, 2)
+		assert.Equal(t, "Author1", page.Quotes[0].Author)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{Author: "Unknown"}, firstPage)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Quotes)
+	})
+}
+
+func TestAuthorMatch(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, author := range []string{"Albert Einstein", "einstein", "Einstein", "Альберт Эйнштейн", "100%_real"} {
+		mustAdd(t, db, models.Quote{Author: author, Quote: "Quote"})
+	}
+
+	tests := []struct {
+		name     string
+		filter   models.QuoteFilter
+		expected []string
+	}{
+		{"ExactByDefault", models.QuoteFilter{Author: "Einstein"}, []string{"3"}},
+		{"Exact", models.QuoteFilter{Author: "Einstein", AuthorMatch: models.AuthorExact}, []string{"3"}},
+		{"IExact", models.QuoteFilter{Author: "EINSTEIN", AuthorMatch: models.AuthorIExact}, []string{"2", "3"}},
+		{"Prefix", models.QuoteFilter{Author: "alb", AuthorMatch: models.AuthorPrefix}, []string{"1"}},
+		{"Contains", models.QuoteFilter{Author: "einstein", AuthorMatch: models.AuthorContains}, []string{"1", "2", "3"}},
+		{"NonASCII", models.QuoteFilter{Author: "эйнштейн", AuthorMatch: models.AuthorContains}, []string{"4"}},
+		{"WildcardsAreLiteral", models.QuoteFilter{Author: "%_", AuthorMatch: models.AuthorContains}, []string{"5"}},
+		{"NoWildcardMatch", models.QuoteFilter{Author: "_", AuthorMatch: models.AuthorPrefix}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := db.GetQuotes(ctx, tt.filter, firstPage)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, q := range page.Quotes {
+				ids = append(ids, q.Id)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestAllQuotes(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+	}
+
+	var ids []string
+	for q, err := range db.AllQuotes(ctx) {
+		require.NoError(t, err)
+		ids = append(ids, q.Id)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, err := range db.AllQuotes(canceled) {
+		assert.ErrorIs(t, err, context.Canceled)
+		break
+	}
+}
+
+func TestGetQuoteByID(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		quote, err := db.GetQuoteByID(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		for _, id := range []string{"2", "abc"} {
+			_, err := db.GetQuoteByID(ctx, id)
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+		}
+	})
+}
+
+func TestSearchQuotes(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Let it be, let it be"})
+	mustAdd(t, db, models.Quote{Author: "Author2", Quote: "To be or not to be"})
+	mustAdd(t, db, models.Quote{Author: "Author3", Quote: "Nothing to see <here>"})
+	mustAdd(t, db, models.Quote{Author: "Author4", Quote: "Быть или не быть"})
+
+	t.Run("Ranked", func(t *testing.T) {
+		results, err := db.SearchQuotes(ctx, "BE", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Greater(t, results[0].Rank, 0.0)
+		assert.GreaterOrEqual(t, results[0].Rank, results[1].Rank)
+	})
+
+	t.Run("AllTermsRequired", func(t *testing.T) {
+		results, err := db.SearchQuotes(ctx, "not be", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "2", results[0].Id)
+		assert.Equal(t, "To <mark>be</mark> or <mark>not</mark> to <mark>be</mark>", results[0].Snippet)
+	})
+
+	t.Run("SnippetIsEscaped", func(t *testing.T) {
+		results, err := db.SearchQuotes(ctx, "here", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Nothing to see &lt;<mark>here</mark>&gt;", results[0].Snippet)
+	})
+
+	t.Run("Unicode", func(t *testing.T) {
+		results, err := db.SearchQuotes(ctx, "быть", 10)
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "<mark>Быть</mark> или не <mark>быть</mark>", results[0].Snippet)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		results, err := db.SearchQuotes(ctx, "be", 1)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		for _, q := range []string{"missing", "\"*"} {
+			results, err := db.SearchQuotes(ctx, q, 10)
+			assert.NoError(t, err)
+			assert.NotNil(t, results)
+			assert.Empty(t, results)
+		}
+	})
+}
+
+func TestGetRandomQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := db.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+
+		quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Quote{{Id: "1", Author: "Author", Quote: "Quote", AuthorId: "1"}}, quotes)
+	})
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Короткая", Language: "ru"})
+	mustAdd(t, db, models.Quote{Author: "Other", Quote: "A much longer quote", Language: "en"})
+
+	t.Run("Filters", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			filter   models.QuoteFilter
+			expected string
+		}{
+			{name: "language", filter: models.QuoteFilter{Language: "en"}, expected: "3"},
+			{name: "max length counts characters", filter: models.QuoteFilter{Language: "ru", MaxLength: 8}, expected: "2"},
+			{name: "author", filter: models.QuoteFilter{Author: "Other"}, expected: "3"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				quotes, err := db.GetRandomQuote(ctx, tt.filter, models.RandomRequest{N: 1})
+				require.NoError(t, err)
+				require.Len(t, quotes, 1)
+				assert.Equal(t, tt.expected, quotes[0].Id)
+			})
+		}
+
+		_, err := db.GetRandomQuote(ctx, models.QuoteFilter{MaxLength: 4}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Several", func(t *testing.T) {
+		quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 2})
+		require.NoError(t, err)
+		require.Len(t, quotes, 2)
+		assert.NotEqual(t, quotes[0].Id, quotes[1].Id)
+
+		quotes, err = db.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 10})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, q := range quotes {
+			ids = append(ids, q.Id)
+		}
+		assert.ElementsMatch(t, []string{"1", "2", "3"}, ids)
+	})
+
+	t.Run("Seeded", func(t *testing.T) {
+		seed := models.ParseSeed("card")
+		r := models.RandomRequest{N: 2, Seed: &seed}
+
+		// The quotes are 1, 2 and 3, so an index picks the quote with the next id.
+		var expected []string
+		for _, i := range models.SeededIndexes(seed, 2, 3) {
+			expected = append(expected, strconv.Itoa(i+1))
+		}
+
+		for range 3 {
+			quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{}, r)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, q := range quotes {
+				ids = append(ids, q.Id)
+			}
+			assert.Equal(t, expected, ids)
+		}
+
+		quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{Author: "Other"}, r)
+		require.NoError(t, err)
+		require.Len(t, quotes, 1)
+		assert.Equal(t, "3", quotes[0].Id)
+
+		_, err = db.GetRandomQuote(ctx, models.QuoteFilter{Author: "Nobody"}, r)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestWeightedRandomQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	for i := range 10 {
+		mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote " + strconv.Itoa(i+1)})
+	}
+
+	t.Run("Counters", func(t *testing.T) {
+		assert.NoError(t, db.ViewQuote(ctx, "1"))
+		assert.NoError(t, db.LikeQuote(ctx, "1"))
+		assert.ErrorIs(t, db.ViewQuote(ctx, "42"), repository.ErrNotFound)
+		assert.ErrorIs(t, db.LikeQuote(ctx, "42"), repository.ErrNotFound)
+	})
+
+	// picks counts how often each quote is picked first.
+	picks := func(t *testing.T, w models.Weight) map[string]int {
+		counts := make(map[string]int)
+		for range 500 {
+			quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{}, models.RandomRequest{N: 1, Weight: w})
+			require.NoError(t, err)
+			require.Len(t, quotes, 1)
+			counts[quotes[0].Id]++
+		}
+		return counts
+	}
+
+	t.Run("Popular", func(t *testing.T) {
+		for range 20 {
+			require.NoError(t, db.LikeQuote(ctx, "5"))
+		}
+
+		// Quote 5 weighs 201 against 12 for quote 1 and 1 for the others.
+		counts := picks(t, models.WeightPopular)
+		assert.Greater(t, counts["5"], 350)
+	})
+
+	t.Run("Recent", func(t *testing.T) {
+		counts := picks(t, models.WeightRecent)
+		assert.Greater(t, counts["10"], counts["1"])
+	})
+
+	t.Run("Several", func(t *testing.T) {
+		quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{Author: "Author"}, models.RandomRequest{N: 20, Weight: models.WeightPopular})
+		require.NoError(t, err)
+
+		ids := make(map[string]bool)
+		for _, q := range quotes {
+			ids[q.Id] = true
+		}
+		assert.Len(t, ids, 10)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := db.GetRandomQuote(ctx, models.QuoteFilter{Author: "Nobody"}, models.RandomRequest{N: 1, Weight: models.WeightRecent})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("DeletedQuote", func(t *testing.T) {
+		require.NoError(t, db.DeleteQuote(ctx, "1"))
+		assert.ErrorIs(t, db.LikeQuote(ctx, "1"), repository.ErrNotFound)
+	})
+}
+
+func TestGetQuoteAt(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	t.Run("Empty", func(t *testing.T) {
+		count, err := db.CountQuotes(ctx, models.QuoteFilter{})
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+
+		_, err = db.GetQuoteAt(ctx, models.QuoteFilter{}, 0)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote1", Language: "en"})
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote2"})
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote3", Language: "en"})
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := db.CountQuotes(ctx, models.QuoteFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		count, err = db.CountQuotes(ctx, models.QuoteFilter{Language: "en"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		quote, err := db.GetQuoteAt(ctx, models.QuoteFilter{}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "2", quote.Id)
+
+		quote, err = db.GetQuoteAt(ctx, models.QuoteFilter{Language: "en"}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "3", quote.Id)
+	})
+
+	t.Run("OutOfRange", func(t *testing.T) {
+		_, err := db.GetQuoteAt(ctx, models.QuoteFilter{Language: "en"}, 2)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestUpdateQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		quote, err := db.UpdateQuote(ctx, models.Quote{Id: "1", Author: "New Author", Quote: "New Quote"})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "New Author", Quote: "New Quote", AuthorId: "2"}, quote)
+
+		quote, err = db.GetQuoteByID(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "New Author", quote.Author)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := db.UpdateQuote(ctx, models.Quote{Id: "2", Author: "New Author", Quote: "New Quote"})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestPatchQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		text := "New Quote"
+		quote, err := db.PatchQuote(ctx, "1", models.QuotePatch{Quote: &text})
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Quote{Id: "1", Author: "Author", Quote: "New Quote", AuthorId: "1"}, quote)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		text := "New Quote"
+		_, err := db.PatchQuote(ctx, "2", models.QuotePatch{Quote: &text})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestDeleteQuote(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "Quote"})
+
+	t.Run("Success", func(t *testing.T) {
+		err := db.DeleteQuote(ctx, "1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := db.DeleteQuote(ctx, "1")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.Contains(t, err.Error(), "quote not found")
+	})
+}
+
+func TestPagination(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	for _, author := range []string{"Author1", "Author2", "Author1", "Author1"} {
+		mustAdd(t, db, models.Quote{Author: author, Quote: "Quote"})
+	}
+
+	page, err := db.GetQuotes(ctx, models.QuoteFilter{Author: "Author1"}, models.PageRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Quotes, 2)
+	assert.Equal(t, "1", page.Quotes[0].Id)
+	assert.Equal(t, "3", page.Quotes[1].Id)
+	assert.Equal(t, models.EncodeCursor("3"), page.NextCursor)
+
+	page, err = db.GetQuotes(ctx, models.QuoteFilter{Author: "Author1"}, models.PageRequest{After: 3, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Quotes, 1)
+	assert.Equal(t, "4", page.Quotes[0].Id)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestAuthors(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Лев Толстой", Quote: "Quote1"})
+	mustAdd(t, db, models.Quote{Author: "Author2", Quote: "Quote2"})
+	mustAdd(t, db, models.Quote{Author: "лёв толстой.", Quote: "Quote3"})
+	_, err := db.AddQuotes(ctx, []models.Quote{{Author: "ЛЕВ  ТОЛСТОЙ", Quote: "Quote4"}, {Author: "Author3", Quote: "Quote5"}})
+	require.NoError(t, err)
+
+	t.Run("VariantsShareAuthor", func(t *testing.T) {
+		page, err := db.GetAuthors(ctx, firstPage)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{
+			{Id: "1", Name: "Лев Толстой", QuoteCount: 3},
+			{Id: "2", Name: "Author2", QuoteCount: 1},
+			{Id: "3", Name: "Author3", QuoteCount: 1},
+		}, page.Authors)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		page, err := db.GetAuthors(ctx, models.PageRequest{After: 1, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{{Id: "2", Name: "Author2", QuoteCount: 1}}, page.Authors)
+		assert.Equal(t, models.EncodeCursor("2"), page.NextCursor)
+	})
+
+	t.Run("AuthorQuotes", func(t *testing.T) {
+		page, err := db.GetQuotes(ctx, models.QuoteFilter{AuthorId: "1"}, firstPage)
+		require.NoError(t, err)
+
+		var ids []string
+		for _, q := range page.Quotes {
+			ids = append(ids, q.Id)
+		}
+		assert.Equal(t, []string{"1", "3", "4"}, ids)
+	})
+
+	t.Run("GetAuthorByID", func(t *testing.T) {
+		author, err := db.GetAuthorByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, &models.Author{Id: "1", Name: "Лев Толстой", QuoteCount: 3}, author)
+
+		_, err = db.GetAuthorByID(ctx, "42")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("AuthorWithoutQuotesIsHidden", func(t *testing.T) {
+		quote, err := db.UpdateQuote(ctx, models.Quote{Id: "2", Author: "author3", Quote: "Quote2"})
+		require.NoError(t, err)
+		assert.Equal(t, "3", quote.AuthorId)
+
+		_, err = db.GetAuthorByID(ctx, "2")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		page, err := db.GetAuthors(ctx, firstPage)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Author{
+			{Id: "1", Name: "Лев Толстой", QuoteCount: 3},
+			{Id: "3", Name: "Author3", QuoteCount: 2},
+		}, page.Authors)
+	})
+}
+
+func TestTags(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author1", Quote: "Quote1", Tags: []string{"humor", "stoicism"}})
+	mustAdd(t, db, models.Quote{Author: "Author2", Quote: "Quote2", Tags: []string{"humor"}})
+	_, err := db.AddQuotes(ctx, []models.Quote{
+		{Author: "Author3", Quote: "Quote3", Tags: []string{"stoicism"}},
+		{Author: "Author4", Quote: "Quote4"},
+	})
+	require.NoError(t, err)
+
+	t.Run("Stored", func(t *testing.T) {
+		quote, err := db.GetQuoteByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"humor", "stoicism"}, quote.Tags)
+
+		quote, err = db.GetQuoteByID(ctx, "4")
+		require.NoError(t, err)
+		assert.Nil(t, quote.Tags)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			tags     []string
+			expected []string
+		}{
+			{name: "one tag", tags: []string{"humor"}, expected: []string{"1", "2"}},
+			{name: "all tags must match", tags: []string{"humor", "stoicism"}, expected: []string{"1"}},
+			{name: "unknown tag", tags: []string{"unknown"}, expected: nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				page, err := db.GetQuotes(ctx, models.QuoteFilter{Tags: tt.tags}, firstPage)
+				require.NoError(t, err)
+
+				var ids []string
+				for _, q := range page.Quotes {
+					ids = append(ids, q.Id)
+				}
+				assert.Equal(t, tt.expected, ids)
+			})
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		for range 10 {
+			quotes, err := db.GetRandomQuote(ctx, models.QuoteFilter{Tags: []string{"stoicism"}, Author: "Author3"}, models.RandomRequest{N: 1})
+			require.NoError(t, err)
+			assert.Equal(t, "3", quotes[0].Id)
+		}
+
+		_, err := db.GetRandomQuote(ctx, models.QuoteFilter{Tags: []string{"unknown"}}, models.RandomRequest{N: 1})
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		tags, err := db.GetTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Tag{{Name: "humor", QuoteCount: 2}, {Name: "stoicism", QuoteCount: 2}}, tags)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		quote, err := db.UpdateQuote(ctx, models.Quote{Id: "2", Author: "Author2", Quote: "Quote2", Tags: []string{"wisdom"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"wisdom"}, quote.Tags)
+
+		text := "Quote1, edited"
+		quote, err = db.PatchQuote(ctx, "1", models.QuotePatch{Quote: &text})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"humor", "stoicism"}, quote.Tags)
+
+		var none []string
+		quote, err = db.PatchQuote(ctx, "3", models.QuotePatch{Tags: &none})
+		require.NoError(t, err)
+		assert.Nil(t, quote.Tags)
+
+		require.NoError(t, db.DeleteQuote(ctx, "1"))
+
+		tags, err := db.GetTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Tag{{Name: "wisdom", QuoteCount: 1}}, tags)
+	})
+}
+
+func TestMigrateLinksExistingQuotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+
+	old, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = old.Exec(`
+		CREATE TABLE quotes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			author TEXT NOT NULL CHECK (length(author) <= 30),
+			quote TEXT NOT NULL
+		);
+		INSERT INTO quotes(author, quote) VALUES ('Author', 'Quote1'), ('Other', 'Quote2'), ('author', 'Quote3');`)
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := sqlite.New(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	page, err := db.GetAuthors(context.Background(), firstPage)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Author{
+		{Id: "1", Name: "Author", QuoteCount: 2},
+		{Id: "2", Name: "Other", QuoteCount: 1},
+	}, page.Authors)
+
+	quote := mustAdd(t, db, models.Quote{Author: "AUTHOR", Quote: "Quote4"})
+	assert.Equal(t, "1", quote.AuthorId)
+
+	results, err := db.SearchQuotes(context.Background(), "Quote2", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func mustAdd(t *testing.T, repo *sqlite.Database, q models.Quote) *models.Quote {
+	t.Helper()
+
+	quote, err := repo.AddQuote(context.Background(), q)
+	require.NoError(t, err)
+
+	return quote
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.Background()
+
+	mustAdd(t, db, models.Quote{Author: "Author", Quote: "old text"})
+
+	text := "new text"
+	_, err := db.PatchQuote(ctx, "1", models.QuotePatch{Quote: &text})
+	require.NoError(t, err)
+
+	results, err := db.SearchQuotes(ctx, "old", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = db.SearchQuotes(ctx, "new", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	require.NoError(t, db.DeleteQuote(ctx, "1"))
+
+	results, err = db.SearchQuotes(ctx, "new", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}